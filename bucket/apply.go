@@ -0,0 +1,168 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// DeleteError records a failure to delete a single object.
+type DeleteError struct {
+	// ID is the ID of the object that couldn't be deleted.
+	ID string
+	// Err is the error returned by the object's Delete method.
+	Err error
+}
+
+func (e DeleteError) Error() string {
+	return fmt.Sprintf("%s: %v", e.ID, e.Err)
+}
+
+// DeleteErrors collects every failed deletion from a cleanup that continued past errors.
+type DeleteErrors []DeleteError
+
+func (e DeleteErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return fmt.Sprintf("%d deletions failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Apply deletes every object that decisions doesn't keep using the default Cleaner, stopping at the first error. Use Cleaner.Apply for the Result.
+func Apply(decisions Decisions) error {
+	_, err := Cleaner{}.Apply(decisions)
+	return err
+}
+
+// Apply deletes every object that decisions doesn't keep. If CheckLimits fails nothing is deleted. Unless ContinueOnError is set it stops at the first error and returns it unchanged. Otherwise every deletion is attempted and any failures are returned as DeleteErrors in the order they appear in decisions.
+func (c Cleaner) Apply(decisions Decisions) (Result, error) {
+	return c.ApplyContext(context.Background(), decisions)
+}
+
+// ApplyContext is like Apply but checks ctx before each deletion. Once ctx is done no further objects are deleted and ctx.Err() is returned along with the counts so far. Objects implementing agerotate.ContextDeleter are deleted with ctx.
+func (c Cleaner) ApplyContext(ctx context.Context, decisions Decisions) (Result, error) {
+	if err := c.CheckLimits(decisions); err != nil {
+		return Result{}, err
+	}
+	result := newResult(decisions)
+	deletions := Decisions{}
+	for _, d := range decisions {
+		if !d.Keep {
+			deletions = append(deletions, d)
+		}
+	}
+	if c.Workers > 1 {
+		return c.applyConcurrent(ctx, deletions, result)
+	}
+
+	errs := DeleteErrors{}
+	for _, d := range deletions {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := deleteObject(ctx, d.Object); err != nil {
+			result.Failed++
+			if !c.ContinueOnError {
+				return result, err
+			}
+			errs = append(errs, DeleteError{ID: d.Object.ID(), Err: err})
+			continue
+		}
+		result.recordDeleted(d)
+	}
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
+
+// applyConcurrent deletes objects using Workers goroutines. Deletions are started in plan order. Unless ContinueOnError is set, no new deletions are started after one fails, deletions already in progress are allowed to finish, and the failure that comes first in plan order is returned. DeleteErrors are in plan order regardless of the order deletions finish.
+func (c Cleaner) applyConcurrent(ctx context.Context, deletions Decisions, result Result) (Result, error) {
+	feedCtx, stopFeeding := context.WithCancel(ctx)
+	defer stopFeeding()
+	errs := make([]error, len(deletions))
+	started := make([]bool, len(deletions))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < c.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// The feeder may hand out an index after a failure stops feeding, so each worker checks before it starts a deletion.
+				if feedCtx.Err() != nil {
+					continue
+				}
+				started[i] = true
+				errs[i] = deleteObject(ctx, deletions[i].Object)
+				if errs[i] != nil && !c.ContinueOnError {
+					stopFeeding()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range deletions {
+		if feedCtx.Err() != nil {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-feedCtx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var firstErr error
+	deleteErrs := DeleteErrors{}
+	unstarted := false
+	for i, d := range deletions {
+		switch {
+		case !started[i]:
+			unstarted = true
+		case errs[i] != nil:
+			result.Failed++
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			deleteErrs = append(deleteErrs, DeleteError{ID: d.Object.ID(), Err: errs[i]})
+		default:
+			result.recordDeleted(d)
+		}
+	}
+	if firstErr != nil && !c.ContinueOnError {
+		return result, firstErr
+	}
+	if err := ctx.Err(); err != nil && unstarted {
+		return result, err
+	}
+	if len(deleteErrs) > 0 {
+		return result, deleteErrs
+	}
+	return result, nil
+}
+
+// deleteObject deletes o, passing ctx along if o supports it.
+func deleteObject(ctx context.Context, o agerotate.Object) error {
+	if deleter, ok := o.(agerotate.ContextDeleter); ok {
+		return deleter.DeleteContext(ctx)
+	}
+	return o.Delete()
+}
@@ -0,0 +1,273 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+type testFailObject struct {
+	testObject
+}
+
+func (t *testFailObject) Delete() error {
+	return fmt.Errorf("Can't delete %s", t.ID())
+}
+
+func TestApplyStopsOnError(t *testing.T) {
+	first := &testObject{age: 1 * time.Second}
+	failing := &testFailObject{testObject{age: 2 * time.Second}}
+	last := &testObject{age: 3 * time.Second}
+	decisions := Decisions{
+		{Object: first},
+		{Object: failing},
+		{Object: last},
+	}
+
+	expected := "Can't delete 2s"
+	result, err := Cleaner{}.Apply(decisions)
+	if err == nil {
+		t.Fatalf("Expected error %q, got nil", expected)
+	}
+	if err.Error() != expected {
+		t.Fatalf("Expected error %q, got %q", expected, err)
+	}
+	if !first.deleted {
+		t.Fatalf("Expected first object to be deleted")
+	}
+	if last.deleted {
+		t.Fatalf("Expected processing to stop at the failure")
+	}
+	if result.Deleted != 1 || result.Failed != 1 {
+		t.Fatalf("Expected 1 deleted and 1 failed, got %+v", result)
+	}
+}
+
+func TestApplyContinueOnError(t *testing.T) {
+	kept := &testObject{age: 1 * time.Second}
+	firstFail := &testFailObject{testObject{age: 2 * time.Second}}
+	middle := &testObject{age: 3 * time.Second}
+	secondFail := &testFailObject{testObject{age: 4 * time.Second}}
+	last := &testObject{age: 5 * time.Second}
+	decisions := Decisions{
+		{Object: kept, Keep: true},
+		{Object: firstFail},
+		{Object: middle},
+		{Object: secondFail},
+		{Object: last},
+	}
+
+	result, err := Cleaner{ContinueOnError: true}.Apply(decisions)
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	errs, ok := err.(DeleteErrors)
+	if !ok {
+		t.Fatalf("Expected DeleteErrors, got %T", err)
+	}
+	if len(errs) != 2 || errs[0].ID != "2s" || errs[1].ID != "4s" {
+		t.Fatalf("Expected failures for 2s and 4s, got %v", errs)
+	}
+	expected := "2 deletions failed: 2s: Can't delete 2s; 4s: Can't delete 4s"
+	if err.Error() != expected {
+		t.Fatalf("Expected error %q, got %q", expected, err)
+	}
+	if kept.deleted || !middle.deleted || !last.deleted {
+		t.Fatalf("Expected every deletable object to be deleted")
+	}
+	if result.Kept != 1 || result.Deleted != 2 || result.Failed != 2 {
+		t.Fatalf("Expected 1 kept, 2 deleted, 2 failed, got %+v", result)
+	}
+}
+
+type testCancelObject struct {
+	testObject
+	cancel context.CancelFunc
+}
+
+func (t *testCancelObject) Delete() error {
+	t.cancel()
+	return t.testObject.Delete()
+}
+
+type testContextObject struct {
+	testObject
+	ctx context.Context
+}
+
+func (t *testContextObject) DeleteContext(ctx context.Context) error {
+	t.ctx = ctx
+	return t.testObject.Delete()
+}
+
+func TestApplyContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := &testCancelObject{testObject{age: 1 * time.Second}, cancel}
+	second := &testContextObject{testObject: testObject{age: 2 * time.Second}}
+	decisions := Decisions{
+		{Object: &testObject{age: 0}, Keep: true},
+		{Object: first},
+		{Object: second},
+	}
+
+	result, err := Cleaner{ContinueOnError: true}.ApplyContext(ctx, decisions)
+	if err != context.Canceled {
+		t.Fatalf("Expected %q, got %v", context.Canceled, err)
+	}
+	if !first.deleted || second.deleted {
+		t.Fatalf("Expected only the first object to be deleted")
+	}
+	if result.Kept != 1 || result.Deleted != 1 || result.Failed != 0 {
+		t.Fatalf("Expected 1 kept and 1 deleted, got %+v", result)
+	}
+
+	ctx = context.WithValue(context.Background(), testContextKey("key"), "value")
+	if _, err := (Cleaner{}).ApplyContext(ctx, decisions[2:]); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	if !second.deleted || second.ctx != ctx {
+		t.Fatalf("Expected DeleteContext to be called with the context")
+	}
+}
+
+type testContextKey string
+
+type testSlowObject struct {
+	age      time.Duration
+	fail     bool
+	deleted  bool
+	inFlight *int32
+	maxSeen  *int32
+}
+
+func (t *testSlowObject) Age() time.Duration {
+	return t.age
+}
+
+func (t *testSlowObject) ID() string {
+	return t.age.String()
+}
+
+func (t *testSlowObject) Delete() error {
+	n := atomic.AddInt32(t.inFlight, 1)
+	defer atomic.AddInt32(t.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(t.maxSeen)
+		if n <= max || atomic.CompareAndSwapInt32(t.maxSeen, max, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	if t.fail {
+		return fmt.Errorf("Can't delete %s", t.ID())
+	}
+	t.deleted = true
+	return nil
+}
+
+func TestApplyConcurrent(t *testing.T) {
+	for _, tc := range []struct {
+		id              string
+		workers         int
+		continueOnError bool
+		failing         []int
+		expectedErr     string
+	}{
+		{
+			id:      "Sequential",
+			workers: 1,
+		},
+		{
+			id:      "Four workers",
+			workers: 4,
+		},
+		{
+			id:              "Four workers, continue on error",
+			workers:         4,
+			continueOnError: true,
+			failing:         []int{27, 13, 21},
+			expectedErr:     "3 deletions failed: 23s: Can't delete 23s; 31s: Can't delete 31s; 37s: Can't delete 37s",
+		},
+		{
+			id:          "Four workers, stop on error",
+			workers:     4,
+			failing:     []int{15, 16},
+			expectedErr: "Can't delete 25s",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		var inFlight, maxSeen int32
+		ranges := []agerotate.Range{{Age: 10 * time.Second, Interval: 0}}
+		objs := make([]*testSlowObject, 40)
+		objects := testBucketObjects{}
+		for i := range objs {
+			objs[i] = &testSlowObject{age: time.Duration(i+10) * time.Second, inFlight: &inFlight, maxSeen: &maxSeen}
+			objects = append(objects, objs[i])
+		}
+		for _, i := range tc.failing {
+			objs[i].fail = true
+		}
+		for i := 0; i < 10; i++ {
+			objs[i].age = time.Duration(i) * time.Second
+		}
+
+		cleaner := Cleaner{Workers: tc.workers, ContinueOnError: tc.continueOnError}
+		result, err := cleaner.Cleanup(ranges, objects)
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("Expected no error, got %q", err)
+			}
+		} else {
+			if err == nil {
+				t.Fatalf("Expected error %q, got nil", tc.expectedErr)
+			}
+			if err.Error() != tc.expectedErr {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+		}
+		if maxSeen > int32(tc.workers) {
+			t.Fatalf("Expected at most %d deletions at once, saw %d", tc.workers, maxSeen)
+		}
+		if result.Kept != 10 {
+			t.Fatalf("Expected 10 kept, got %d", result.Kept)
+		}
+
+		deleted := 0
+		for i, o := range objs {
+			if i < 10 && o.deleted {
+				t.Fatalf("Expected object %v to be kept", o.ID())
+			}
+			if o.deleted {
+				deleted++
+			}
+		}
+		if deleted != result.Deleted || len(tc.failing) < result.Failed {
+			t.Fatalf("Result %+v doesn't match %d deleted", result, deleted)
+		}
+		if tc.continueOnError || tc.expectedErr == "" {
+			if deleted+len(tc.failing) != 30 {
+				t.Fatalf("Expected every deletable object to be deleted, got %d", deleted)
+			}
+		} else if deleted == 30-len(tc.failing) {
+			t.Fatalf("Expected deletion to stop after the failure")
+		} else if started := deleted + result.Failed; started > tc.failing[0]-10+2*tc.workers {
+			// Deletions before the failure in plan order, plus those in progress or handed to a worker when it failed.
+			t.Fatalf("Expected no deletions to start after the failure, %d started", started)
+		}
+	}
+}
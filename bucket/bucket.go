@@ -8,23 +8,25 @@ The above copyright notice and this permission notice shall be included in all c
 THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 */
 
-// bucket captures objects below a certain age, decides which ones should be deleted, and deletes them.
+// bucket captures objects below a certain age, decides which ones should be deleted, and deletes them. Deciding and deleting are separate steps: Plan produces a Decision for every object without touching any data and Apply carries out the deletions.
 package bucket
 
 import (
+	"fmt"
 	"sort"
 	"time"
 
 	"github.com/AgentZombie/agerotate"
 )
 
-// bucket is a container for Object(s) and is intended to hold those objects younger than the Age of the Range but older than younger buckets.
+// bucket is a container for Object(s) and is intended to hold those objects younger than the Age of the Range but older than younger buckets. A bucket for a Range with a Count instead holds the Count youngest objects not held by an earlier count bucket.
 type bucket struct {
-	agerotate.Range
+	*agerotate.Range
 	objects []agerotate.Object
 }
 
-func newBucket(r agerotate.Range) *bucket {
+// newBucket returns an empty bucket for r, which decisions point to so callers can tell which of their ranges each belongs to.
+func newBucket(r *agerotate.Range) *bucket {
 	return &bucket{
 		r,
 		[]agerotate.Object{},
@@ -39,24 +41,54 @@ func (b bucket) Age() time.Duration {
 	return b.Range.Age
 }
 
-// Cleanup sorts the objects in the bucket by Age then deletes objects according to the Interval. The first object in the bucket is always retained. For each object thereafter, if the age of the object is less than the age of the last retained object plus Interval, the newer object is deleted. If the next object is older than the age of the last retained object plus Interval, the newer object is retained and processing continues.
-func (b *bucket) Cleanup() error {
-	if len(b.objects) < 2 {
-		return nil
+// isCount reports whether the bucket holds a fixed number of objects rather than objects below an age.
+func (b bucket) isCount() bool {
+	return b.Range.Count > 0
+}
+
+// plan sorts the objects in the bucket by Age then decides which objects to delete. Objects in a count bucket are always retained. Otherwise the decisions are made by the bucket's selector.
+func (b *bucket) plan(t agerotate.Timeline) Decisions {
+	decisions := make(Decisions, 0, len(b.objects))
+	if len(b.objects) == 0 {
+		return decisions
 	}
 
-	sort.Sort(agerotate.ObjectsByAge{b.objects})
-	baseAge := b.objects[0].Age()
-	for _, o := range b.objects[1:] {
-		oAge := o.Age()
-		if oAge-baseAge < b.Range.Interval {
-			err := o.Delete()
-			if err != nil {
-				return err
-			}
-		} else {
-			baseAge = oAge
+	sort.Sort(agerotate.ObjectsByAge{O: b.objects})
+	if b.isCount() {
+		for _, o := range b.objects {
+			decisions = append(decisions, Decision{
+				Object: o,
+				Range:  b.Range,
+				Keep:   true,
+				Reason: fmt.Sprintf("one of the %d youngest objects", b.Range.Count),
+			})
 		}
+		return decisions
+	}
+
+	selections := b.selector().Select(*b.Range, b.objects, t)
+	for i, o := range b.objects {
+		decisions = append(decisions, Decision{
+			Object:   o,
+			Range:    b.Range,
+			Keep:     selections[i].Keep,
+			Reason:   selections[i].Reason,
+			Neighbor: selections[i].Neighbor,
+		})
+	}
+	return decisions
+}
+
+// selector returns the Range's Selector if it has one. Otherwise it returns the selector for the Range's Period if it has one, the selector for aligned slots if it's Aligned, or KeepYoungest.
+func (b bucket) selector() agerotate.Selector {
+	switch {
+	case b.Range.Selector != nil:
+		return b.Range.Selector
+	case b.Range.Period != agerotate.NoPeriod:
+		return periodSelector{}
+	case b.Range.Aligned:
+		return alignedSelector{}
+	default:
+		return KeepYoungest{}
 	}
-	return nil
 }
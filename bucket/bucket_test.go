@@ -96,14 +96,14 @@ func TestCleanup(t *testing.T) {
 	} {
 		t.Logf("Testing case %q", tc.id)
 
-		b := newBucket(agerotate.Range{irrelevantDuration, tc.interval})
+		b := newBucket(&agerotate.Range{Age: irrelevantDuration, Interval: tc.interval})
 		objs := make([]*testObject, len(tc.objects))
 		for i := range tc.objects {
 			objs[i] = &testObject{age: tc.objects[i]}
 			b.Add(objs[i])
 		}
 
-		b.Cleanup()
+		Apply(b.plan(agerotate.Timeline{}))
 
 		if len(tc.expected) != len(b.objects) {
 			t.Fatalf("Expected %v results, got %v", len(tc.expected), len(b.objects))
@@ -119,3 +119,125 @@ func TestCleanup(t *testing.T) {
 		}
 	}
 }
+
+func TestPlanPeriods(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone database: %v", err)
+	}
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	deleted := true
+
+	for _, tc := range []struct {
+		id       string
+		period   agerotate.Period
+		location *time.Location
+		objects  []time.Duration
+		expected []bool
+	}{
+		{
+			id:       "Daily in UTC",
+			period:   agerotate.Day,
+			location: time.UTC,
+			objects:  []time.Duration{1 * time.Hour, 10 * time.Hour, 13 * time.Hour, 20 * time.Hour, 40 * time.Hour},
+			expected: []bool{!deleted, deleted, !deleted, deleted, !deleted},
+		},
+		{
+			id:       "Daily in New York",
+			period:   agerotate.Day,
+			location: newYork,
+			objects:  []time.Duration{1 * time.Hour, 10 * time.Hour, 13 * time.Hour, 20 * time.Hour, 40 * time.Hour},
+			expected: []bool{!deleted, !deleted, deleted, deleted, !deleted},
+		},
+		{
+			id:       "Weekly, shuffled",
+			period:   agerotate.Week,
+			location: time.UTC,
+			objects:  []time.Duration{40 * time.Hour, 7 * 24 * time.Hour, 1 * time.Hour, 8 * 24 * time.Hour},
+			expected: []bool{deleted, !deleted, !deleted, deleted},
+		},
+		{
+			id:       "Monthly",
+			period:   agerotate.Month,
+			location: time.UTC,
+			objects:  []time.Duration{1 * time.Hour, 16 * 24 * time.Hour, 17 * 24 * time.Hour},
+			expected: []bool{!deleted, deleted, !deleted},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+
+		b := newBucket(&agerotate.Range{Age: 365 * 24 * time.Hour, Period: tc.period})
+		objs := make([]*testObject, len(tc.objects))
+		for i := range tc.objects {
+			objs[i] = &testObject{age: tc.objects[i]}
+			b.Add(objs[i])
+		}
+
+		Apply(b.plan(agerotate.Timeline{Now: now, Location: tc.location}))
+
+		for i := range tc.expected {
+			if tc.expected[i] != objs[i].deleted {
+				got := make([]bool, len(objs))
+				for j := range objs {
+					got[j] = objs[j].deleted
+				}
+				t.Fatalf("Expected deleted: %v, got %v", tc.expected, got)
+			}
+		}
+	}
+}
+
+func TestPlanSlots(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	deleted := true
+	ages := []time.Duration{1 * time.Hour, 5 * time.Hour, 7 * time.Hour, 11 * time.Hour, 13 * time.Hour}
+
+	for _, tc := range []struct {
+		id       string
+		now      time.Time
+		anchor   time.Time
+		offset   time.Duration
+		expected []bool
+	}{
+		{
+			id:       "Epoch anchor",
+			now:      now,
+			anchor:   time.Unix(0, 0),
+			expected: []bool{deleted, !deleted, deleted, !deleted, !deleted},
+		},
+		{
+			id:       "Epoch anchor, two hours later",
+			now:      now.Add(2 * time.Hour),
+			anchor:   time.Unix(0, 0),
+			offset:   2 * time.Hour,
+			expected: []bool{deleted, !deleted, deleted, !deleted, !deleted},
+		},
+		{
+			id:       "Anchor at 03:00",
+			now:      now,
+			anchor:   time.Date(2026, time.January, 1, 3, 0, 0, 0, time.UTC),
+			expected: []bool{!deleted, deleted, !deleted, deleted, !deleted},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+
+		b := newBucket(&agerotate.Range{Age: 24 * time.Hour, Interval: 6 * time.Hour, Aligned: true})
+		objs := make([]*testObject, len(ages))
+		for i := range ages {
+			objs[i] = &testObject{age: ages[i] + tc.offset}
+			b.Add(objs[i])
+		}
+
+		Apply(b.plan(agerotate.Timeline{Now: tc.now, Location: time.UTC, Anchor: tc.anchor}))
+
+		for i := range tc.expected {
+			if tc.expected[i] != objs[i].deleted {
+				got := make([]bool, len(objs))
+				for j := range objs {
+					got[j] = objs[j].deleted
+				}
+				t.Fatalf("Expected deleted: %v, got %v", tc.expected, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"fmt"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// enforceBudget marks kept objects for deletion until the total size of the kept objects is at most maxBytes. The oldest range is thinned first by repeatedly deleting every other kept object, oldest first, until one remains. Younger ranges are then thinned the same way. If the budget still isn't met, the last object in each range is deleted, oldest range first. Objects without a size are never chosen since deleting them frees nothing.
+func (d Decisions) enforceBudget(maxBytes int64) {
+	if maxBytes <= 0 {
+		return
+	}
+	var keptBytes int64
+	for _, decision := range d {
+		if decision.Keep {
+			keptBytes += agerotate.SizeOf(decision.Object)
+		}
+	}
+	if keptBytes <= maxBytes {
+		return
+	}
+
+	reason := fmt.Sprintf("deleted to fit within storage budget of %d bytes", maxBytes)
+	drop := func(i int) {
+		keptBytes -= agerotate.SizeOf(d[i].Object)
+		d[i].Keep = false
+		d[i].Reason = reason
+	}
+
+	groups := d.keptByRange()
+	for g := len(groups) - 1; g >= 0 && keptBytes > maxBytes; g-- {
+		kept := groups[g]
+		for len(kept) > 1 && keptBytes > maxBytes {
+			survivors := []int{}
+			for i := len(kept) - 1; i >= 0; i-- {
+				if (len(kept)-1-i)%2 == 1 && keptBytes > maxBytes {
+					drop(kept[i])
+				} else {
+					survivors = append([]int{kept[i]}, survivors...)
+				}
+			}
+			kept = survivors
+		}
+		groups[g] = kept
+	}
+	for g := len(groups) - 1; g >= 0 && keptBytes > maxBytes; g-- {
+		for _, i := range groups[g] {
+			if keptBytes > maxBytes {
+				drop(i)
+			}
+		}
+	}
+}
+
+// keptByRange returns the indexes of the kept objects that have a size, grouped by range in plan order. Within each group indexes are in plan order, youngest first.
+func (d Decisions) keptByRange() [][]int {
+	groups := [][]int{}
+	var current *agerotate.Range
+	for i, decision := range d {
+		if i == 0 || decision.Range != current {
+			groups = append(groups, []int{})
+			current = decision.Range
+		}
+		if decision.Keep && agerotate.SizeOf(decision.Object) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], i)
+		}
+	}
+	return groups
+}
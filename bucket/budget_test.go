@@ -0,0 +1,93 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+func TestMaxBytes(t *testing.T) {
+	for _, tc := range []struct {
+		id       string
+		maxBytes int64
+		minKeep  int
+		expected []time.Duration
+	}{
+		{
+			id:       "No budget",
+			maxBytes: 0,
+			expected: []time.Duration{},
+		},
+		{
+			id:       "Within budget",
+			maxBytes: 100,
+			expected: []time.Duration{},
+		},
+		{
+			id:       "One pass over the oldest range",
+			maxBytes: 80,
+			expected: []time.Duration{30 * time.Second, 50 * time.Second},
+		},
+		{
+			id:       "Second pass over the oldest range",
+			maxBytes: 70,
+			expected: []time.Duration{30 * time.Second, 40 * time.Second, 50 * time.Second},
+		},
+		{
+			id:       "Into the younger range",
+			maxBytes: 40,
+			expected: []time.Duration{2 * time.Second, 4 * time.Second, 20 * time.Second, 30 * time.Second, 40 * time.Second, 50 * time.Second},
+		},
+		{
+			id:       "Everything",
+			maxBytes: 5,
+			expected: []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second, 20 * time.Second, 30 * time.Second, 40 * time.Second, 50 * time.Second, 60 * time.Second},
+		},
+		{
+			id:       "Minimum kept wins, unsized object counts",
+			maxBytes: 5,
+			minKeep:  2,
+			expected: []time.Duration{2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second, 20 * time.Second, 30 * time.Second, 40 * time.Second, 50 * time.Second, 60 * time.Second},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		ranges := []agerotate.Range{
+			{Age: 10 * time.Second, Interval: 0},
+			{Age: 100 * time.Second, Interval: 0},
+		}
+		objects := testBucketObjects{&testObject{age: 70 * time.Second}}
+		for _, age := range []int{1, 2, 3, 4, 5, 20, 30, 40, 50, 60} {
+			objects = append(objects, &testSizedObject{testObject{age: time.Duration(age) * time.Second}, 10})
+		}
+
+		decisions, err := Cleaner{MaxBytes: tc.maxBytes, MinKeep: tc.minKeep}.Plan(ranges, objects)
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		got := []time.Duration{}
+		for _, d := range decisions {
+			if !d.Keep {
+				got = append(got, d.Object.Age())
+			}
+		}
+		if len(got) != len(tc.expected) {
+			t.Fatalf("Expected deleted %v, got %v", tc.expected, got)
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Fatalf("Expected deleted %v, got %v", tc.expected, got)
+			}
+		}
+	}
+}
@@ -11,48 +11,120 @@ THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLI
 package bucket
 
 import (
+	"context"
+	"sort"
+	"time"
+
 	"github.com/AgentZombie/agerotate"
 )
 
-// Cleanup sets up and invokes actual object cleanup.
+// Cleaner holds the settings that control planning and applying a cleanup. The zero value is ready to use and behaves like the package-level Plan, Apply, and Cleanup functions.
+type Cleaner struct {
+	// ContinueOnError makes Apply attempt every deletion even after one fails.
+	ContinueOnError bool
+	// MaxBytes is a storage budget. After applying the ranges, Plan deletes further objects until the objects it keeps total at most MaxBytes, thinning the oldest ranges first. Only objects implementing agerotate.Sizer count toward the budget. Zero means no budget.
+	MaxBytes int64
+	// MinKeep is the minimum number of objects Plan will keep regardless of the ranges or MaxBytes. When fewer would be kept, the youngest objects marked for deletion are kept instead and marked Protected.
+	MinKeep int
+	// MaxDelete is the largest number of deletions Apply will perform. If a plan calls for more, Apply returns a *LimitError without deleting anything. Zero means no limit.
+	MaxDelete int
+	// MaxDeletePercent is like MaxDelete but is a percentage of the objects in the plan. Zero means no limit.
+	MaxDeletePercent float64
+	// Location is the time zone used to find calendar periods for ranges with a Period. Nil means time.Local.
+	Location *time.Location
+	// Anchor is the time that slots for Aligned ranges are aligned to. The zero value means the Unix epoch.
+	Anchor time.Time
+	// Now is the moment the plan is made as of, used to place objects in calendar periods and aligned slots. The zero value means time.Now. Object ages are reported by the objects themselves, so a Now other than the present only makes sense with objects whose ages are measured from the same moment, such as a fileobject.Glob with the same Now.
+	Now time.Time
+	// Workers is the number of deletions Apply runs at once. Values below 2 delete one object at a time. The set of objects deleted doesn't depend on Workers, only the order in which deletions finish.
+	Workers int
+}
+
+// Cleanup plans the cleanup of objects against the sorted ranges and then applies the plan using the default Cleaner. Use Cleaner.Cleanup for the Result.
 func Cleanup(sortedRanges []agerotate.Range, objects agerotate.Objects) error {
-	buckets := makeBuckets(sortedRanges)
-	overflow, err := readObjects(objects, buckets)
+	_, err := Cleaner{}.Cleanup(sortedRanges, objects)
+	return err
+}
+
+// CleanupContext is like Cleanup but stops when ctx is done.
+func CleanupContext(ctx context.Context, sortedRanges []agerotate.Range, objects agerotate.Objects) error {
+	_, err := Cleaner{}.CleanupContext(ctx, sortedRanges, objects)
+	return err
+}
+
+// Cleanup plans the cleanup of objects against the sorted ranges and then applies the plan, deleting every object the plan doesn't keep.
+func (c Cleaner) Cleanup(sortedRanges []agerotate.Range, objects agerotate.Objects) (Result, error) {
+	return c.CleanupContext(context.Background(), sortedRanges, objects)
+}
+
+// CleanupContext is like Cleanup but stops when ctx is done. See PlanContext and ApplyContext.
+func (c Cleaner) CleanupContext(ctx context.Context, sortedRanges []agerotate.Range, objects agerotate.Objects) (Result, error) {
+	decisions, err := c.PlanContext(ctx, sortedRanges, objects)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
+	return c.ApplyContext(ctx, decisions)
+}
 
-	if err = cleanupBuckets(buckets); err != nil {
-		return err
+// timeline returns the timeline used to place objects in calendar periods and aligned slots.
+func (c Cleaner) timeline() agerotate.Timeline {
+	location := c.Location
+	if location == nil {
+		location = time.Local
 	}
-
-	if err = cleanupOverflow(overflow); err != nil {
-		return err
+	anchor := c.Anchor
+	if anchor.IsZero() {
+		anchor = time.Unix(0, 0)
 	}
-
-	return nil
+	now := c.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return agerotate.Timeline{Now: now, Location: location, Anchor: anchor}
 }
 
 func makeBuckets(sortedRanges []agerotate.Range) []*bucket {
 	buckets := make([]*bucket, len(sortedRanges))
 	for i := range sortedRanges {
-		buckets[i] = newBucket(sortedRanges[i])
+		buckets[i] = newBucket(&sortedRanges[i])
 	}
 	return buckets
 }
 
-// readObjects populates buckets by finding the bucket with the smallest age that's larger than the age of the object. If no buckets are larger than the object it's placed in an overflow list and will be deleted.
-func readObjects(objects agerotate.Objects, buckets []*bucket) ([]agerotate.Object, error) {
+// readObjects populates buckets. Count buckets, in order, are filled with the youngest objects first. Each remaining object goes to the bucket with the smallest age that's larger than the age of the object. If no buckets are larger than the object it's placed in an overflow list and will be deleted. Objects implementing agerotate.ContextLister are listed with ctx.
+func readObjects(ctx context.Context, objects agerotate.Objects, buckets []*bucket) ([]agerotate.Object, error) {
 	overflow := []agerotate.Object{}
-	oList, err := objects.List()
+	var oList []agerotate.Object
+	var err error
+	if lister, ok := objects.(agerotate.ContextLister); ok {
+		oList, err = lister.ListContext(ctx)
+	} else {
+		oList, err = objects.List()
+	}
 	if err != nil {
 		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	oList = append([]agerotate.Object{}, oList...)
+	sort.Stable(agerotate.ObjectsByAge{O: oList})
+	for _, b := range buckets {
+		if b.isCount() {
+			n := b.Count
+			if n > len(oList) {
+				n = len(oList)
+			}
+			b.objects = append(b.objects, oList[:n]...)
+			oList = oList[n:]
+		}
+	}
 
 	for _, o := range oList {
 		found := false
 		for _, b := range buckets {
-			if o.Age() < b.Age() {
+			if !b.isCount() && o.Age() < b.Age() {
 				b.Add(o)
 				found = true
 				break
@@ -64,21 +136,3 @@ func readObjects(objects agerotate.Objects, buckets []*bucket) ([]agerotate.Obje
 	}
 	return overflow, nil
 }
-
-func cleanupBuckets(buckets []*bucket) error {
-	for _, b := range buckets {
-		if err := b.Cleanup(); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func cleanupOverflow(overflow []agerotate.Object) error {
-	for _, o := range overflow {
-		if err := o.Delete(); err != nil {
-			return err
-		}
-	}
-	return nil
-}
@@ -11,6 +11,7 @@ THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLI
 package bucket
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -81,7 +82,7 @@ func TestReadObjects(t *testing.T) {
 
 		buckets := makeBuckets(ranges)
 		objects := testBucketObjects(tc.testObjs)
-		overflow, err := readObjects(objects, buckets)
+		overflow, err := readObjects(context.Background(), objects, buckets)
 		if err != nil {
 			t.Fatalf("Unexpected err: %q", err)
 		}
@@ -111,3 +112,76 @@ func TestReadObjects(t *testing.T) {
 		}
 	}
 }
+
+type testContextObjects struct {
+	testBucketObjects
+	ctx context.Context
+}
+
+func (t *testContextObjects) ListContext(ctx context.Context) ([]agerotate.Object, error) {
+	t.ctx = ctx
+	return t.testBucketObjects, nil
+}
+
+func TestCleanupContext(t *testing.T) {
+	ranges := []agerotate.Range{{Age: 10 * time.Second}}
+	old := &testObject{age: 20 * time.Second}
+	objects := &testContextObjects{testBucketObjects: testBucketObjects{old}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := CleanupContext(ctx, ranges, objects); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	if objects.ctx != ctx {
+		t.Fatalf("Expected ListContext to be called with the context")
+	}
+	if !old.deleted {
+		t.Fatalf("Expected old object to be deleted")
+	}
+
+	old.deleted = false
+	cancel()
+	if err := CleanupContext(ctx, ranges, objects); err != context.Canceled {
+		t.Fatalf("Expected %q, got %v", context.Canceled, err)
+	}
+	if old.deleted {
+		t.Fatalf("Expected nothing deleted after cancellation")
+	}
+}
+
+func TestCleanerNow(t *testing.T) {
+	ranges := []agerotate.Range{{Age: 72 * time.Hour, Period: agerotate.Day}}
+	objs := testBucketObjects{
+		&testObject{age: 2 * time.Hour},
+		&testObject{age: 10 * time.Hour},
+		&testObject{age: 20 * time.Hour},
+	}
+
+	for _, tc := range []struct {
+		id       string
+		now      time.Time
+		expected []bool
+	}{
+		{
+			id:       "Noon",
+			now:      time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC),
+			expected: []bool{true, false, true},
+		},
+		{
+			id:       "Early morning",
+			now:      time.Date(2026, time.October, 17, 5, 0, 0, 0, time.UTC),
+			expected: []bool{true, true, false},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		decisions, err := Cleaner{Now: tc.now, Location: time.UTC}.Plan(ranges, objs)
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		for i := range tc.expected {
+			if decisions[i].Keep != tc.expected[i] {
+				t.Fatalf("Expected decision %d to keep %v, got %+v", i, tc.expected[i], decisions[i])
+			}
+		}
+	}
+}
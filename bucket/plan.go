@@ -0,0 +1,91 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"context"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// Decision records what should happen to a single object and why.
+type Decision struct {
+	// Object is the object the decision applies to.
+	Object agerotate.Object
+	// Range points to the element of the ranges passed to Plan that the object was assigned to, so it can be compared with &ranges[i]. It's nil if the object is older than every range.
+	Range *agerotate.Range
+	// Keep is true if the object should be retained and false if it should be deleted.
+	Keep bool
+	// Reason is a human-readable explanation of the decision.
+	Reason string
+	// Neighbor is the kept object the decision was made against, if any. See agerotate.Selection.
+	Neighbor agerotate.Object
+	// Protected is true if the ranges called for deleting the object but a safeguard kept it.
+	Protected bool
+}
+
+// Decisions is the result of planning a cleanup. Decisions are ordered by range, youngest range first, and by age within each range. Objects older than every range come last.
+type Decisions []Decision
+
+// Plan assigns every object to one of the sorted ranges and decides which objects to keep and which to delete using the default Cleaner. No objects are deleted.
+func Plan(sortedRanges []agerotate.Range, objects agerotate.Objects) (Decisions, error) {
+	return Cleaner{}.Plan(sortedRanges, objects)
+}
+
+// Plan assigns every object to one of the sorted ranges and decides which objects to keep and which to delete. No objects are deleted.
+func (c Cleaner) Plan(sortedRanges []agerotate.Range, objects agerotate.Objects) (Decisions, error) {
+	return c.PlanContext(context.Background(), sortedRanges, objects)
+}
+
+// PlanContext is like Plan but gives up if ctx is done before the objects have been listed.
+func (c Cleaner) PlanContext(ctx context.Context, sortedRanges []agerotate.Range, objects agerotate.Objects) (Decisions, error) {
+	buckets := makeBuckets(sortedRanges)
+	overflow, err := readObjects(ctx, objects, buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := Decisions{}
+	t := c.timeline()
+	for _, b := range buckets {
+		decisions = append(decisions, b.plan(t)...)
+	}
+	for _, o := range overflow {
+		decisions = append(decisions, Decision{
+			Object: o,
+			Reason: "older than every range",
+		})
+	}
+	decisions.enforceBudget(c.MaxBytes)
+	decisions.enforceMinKeep(c.MinKeep)
+	return decisions, nil
+}
+
+// Find returns the decision for the object with the given ID.
+func (d Decisions) Find(id string) (Decision, bool) {
+	for _, decision := range d {
+		if decision.Object.ID() == id {
+			return decision, true
+		}
+	}
+	return Decision{}, false
+}
+
+// Protected returns the decisions where a safeguard overrode a deletion.
+func (d Decisions) Protected() Decisions {
+	protected := Decisions{}
+	for _, decision := range d {
+		if decision.Protected {
+			protected = append(protected, decision)
+		}
+	}
+	return protected
+}
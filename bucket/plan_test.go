@@ -0,0 +1,186 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+func TestPlan(t *testing.T) {
+	ranges := []agerotate.Range{
+		{Age: 10 * time.Second, Interval: 0},
+		{Age: 100 * time.Second, Interval: 30 * time.Second},
+	}
+	objs := []*testObject{
+		{age: 200 * time.Second},
+		{age: 50 * time.Second},
+		{age: 5 * time.Second},
+		{age: 40 * time.Second},
+		{age: 90 * time.Second},
+		{age: 1 * time.Second},
+	}
+	objects := testBucketObjects{}
+	for _, o := range objs {
+		objects = append(objects, o)
+	}
+
+	decisions, err := Plan(ranges, objects)
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	for _, o := range objs {
+		if o.deleted {
+			t.Fatalf("Plan deleted object %v", o.ID())
+		}
+	}
+
+	deleted := true
+	for i, expected := range []struct {
+		age      time.Duration
+		rangeAge time.Duration
+		deleted  bool
+	}{
+		{1 * time.Second, 10 * time.Second, !deleted},
+		{5 * time.Second, 10 * time.Second, !deleted},
+		{40 * time.Second, 100 * time.Second, !deleted},
+		{50 * time.Second, 100 * time.Second, deleted},
+		{90 * time.Second, 100 * time.Second, !deleted},
+		{200 * time.Second, 0, deleted},
+	} {
+		if i >= len(decisions) {
+			t.Fatalf("Expected at least %d decisions, got %d", i+1, len(decisions))
+		}
+		d := decisions[i]
+		if d.Object.Age() != expected.age {
+			t.Fatalf("Decision %d: expected object %v, got %v", i, expected.age, d.Object.Age())
+		}
+		if d.Keep == expected.deleted {
+			t.Fatalf("Decision %d: expected deleted %v, got keep %v (%s)", i, expected.deleted, d.Keep, d.Reason)
+		}
+		if expected.rangeAge == 0 {
+			if d.Range != nil {
+				t.Fatalf("Decision %d: expected no range, got %v", i, d.Range)
+			}
+		} else if d.Range == nil || d.Range.Age != expected.rangeAge {
+			t.Fatalf("Decision %d: expected range age %v, got %v", i, expected.rangeAge, d.Range)
+		} else if d.Range != &ranges[0] && d.Range != &ranges[1] {
+			t.Fatalf("Decision %d: expected range to point into the ranges passed to Plan", i)
+		}
+		if d.Reason == "" {
+			t.Fatalf("Decision %d: missing reason", i)
+		}
+	}
+	if len(decisions) != len(objs) {
+		t.Fatalf("Expected %d decisions, got %d", len(objs), len(decisions))
+	}
+
+	if err := Apply(decisions); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	for _, d := range decisions {
+		if d.Object.(*testObject).deleted == d.Keep {
+			t.Fatalf("Object %v: keep was %v, deleted was %v", d.Object.ID(), d.Keep, !d.Keep)
+		}
+	}
+}
+
+func TestPlanCount(t *testing.T) {
+	for _, tc := range []struct {
+		id       string
+		ranges   []agerotate.Range
+		objects  []time.Duration
+		expected []bool
+		counted  int
+	}{
+		{
+			id:       "Count only",
+			ranges:   []agerotate.Range{{Count: 2}},
+			objects:  []time.Duration{30 * time.Second, 10 * time.Second, 20 * time.Second},
+			expected: []bool{false, true, true},
+			counted:  2,
+		},
+		{
+			id:       "More room than objects",
+			ranges:   []agerotate.Range{{Count: 5}},
+			objects:  []time.Duration{30 * time.Second, 10 * time.Second},
+			expected: []bool{true, true},
+			counted:  2,
+		},
+		{
+			id:       "Count then age",
+			ranges:   []agerotate.Range{{Count: 2}, {Age: 100 * time.Second, Interval: 50 * time.Second}},
+			objects:  []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 60 * time.Second, 200 * time.Second},
+			expected: []bool{true, true, true, false, true, false},
+			counted:  2,
+		},
+		{
+			id:       "Count claims first regardless of position",
+			ranges:   []agerotate.Range{{Age: 100 * time.Second, Interval: 50 * time.Second}, {Count: 1}},
+			objects:  []time.Duration{200 * time.Second, 3 * time.Second, 4 * time.Second},
+			expected: []bool{false, true, true},
+			counted:  1,
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		objs := make([]*testObject, len(tc.objects))
+		objects := testBucketObjects{}
+		for i := range tc.objects {
+			objs[i] = &testObject{age: tc.objects[i]}
+			objects = append(objects, objs[i])
+		}
+
+		decisions, err := Plan(tc.ranges, objects)
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		counted := 0
+		for _, d := range decisions {
+			if d.Range != nil && d.Range.Count > 0 {
+				counted++
+			}
+		}
+		if counted != tc.counted {
+			t.Fatalf("Expected %d objects in the count range, got %d", tc.counted, counted)
+		}
+		if err := Apply(decisions); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		for i := range tc.expected {
+			if tc.expected[i] == objs[i].deleted {
+				t.Fatalf("Object %v: expected kept %v", objs[i].ID(), tc.expected[i])
+			}
+		}
+	}
+}
+
+func TestDecisionsFind(t *testing.T) {
+	ranges := []agerotate.Range{{Age: 100 * time.Second, Interval: 30 * time.Second}}
+	kept := &testObject{age: 10 * time.Second}
+	dropped := &testObject{age: 20 * time.Second}
+	decisions, err := Plan(ranges, testBucketObjects{dropped, kept})
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+
+	d, ok := decisions.Find(dropped.ID())
+	if !ok {
+		t.Fatalf("Expected to find %q", dropped.ID())
+	}
+	if d.Object != dropped || d.Keep || d.Neighbor != kept || d.Range == nil || d.Range.Age != 100*time.Second {
+		t.Fatalf("Unexpected decision %+v", d)
+	}
+	if _, ok := decisions.Find("missing"); ok {
+		t.Fatalf("Expected no decision for a missing ID")
+	}
+}
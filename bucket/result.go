@@ -0,0 +1,88 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"github.com/AgentZombie/agerotate"
+)
+
+// Result counts what happened when a plan was applied. Byte counts come from objects implementing agerotate.Sizer and are zero for objects that don't.
+type Result struct {
+	// Kept is the number of objects the plan retained.
+	Kept int
+	// Deleted is the number of objects successfully deleted.
+	Deleted int
+	// Failed is the number of objects that couldn't be deleted.
+	Failed int
+	// KeptBytes is the total size of the objects the plan retained.
+	KeptBytes int64
+	// ReclaimedBytes is the total size of the objects successfully deleted.
+	ReclaimedBytes int64
+	// Ranges breaks the counts down by range, in the order of the plan.
+	Ranges []RangeResult
+}
+
+// RangeResult counts the objects and bytes kept and deleted within one range.
+type RangeResult struct {
+	// Range is the range being counted. It's nil for objects older than every range.
+	Range          *agerotate.Range
+	Kept           int
+	Deleted        int
+	KeptBytes      int64
+	ReclaimedBytes int64
+}
+
+// Result returns the Result that applying decisions would produce if every deletion succeeded.
+func (d Decisions) Result() Result {
+	result := newResult(d)
+	for _, decision := range d {
+		if !decision.Keep {
+			result.recordDeleted(decision)
+		}
+	}
+	return result
+}
+
+// newResult returns a Result counting the objects decisions keeps, with an entry in Ranges for every range that has objects.
+func newResult(decisions Decisions) Result {
+	result := Result{Ranges: []RangeResult{}}
+	for _, d := range decisions {
+		rr := result.rangeResult(d.Range)
+		if d.Keep {
+			size := agerotate.SizeOf(d.Object)
+			result.Kept++
+			result.KeptBytes += size
+			rr.Kept++
+			rr.KeptBytes += size
+		}
+	}
+	return result
+}
+
+func (r *Result) recordDeleted(d Decision) {
+	size := agerotate.SizeOf(d.Object)
+	rr := r.rangeResult(d.Range)
+	r.Deleted++
+	r.ReclaimedBytes += size
+	rr.Deleted++
+	rr.ReclaimedBytes += size
+}
+
+// rangeResult returns the entry in Ranges for the range, adding one if needed.
+func (r *Result) rangeResult(rng *agerotate.Range) *RangeResult {
+	for i := range r.Ranges {
+		if r.Ranges[i].Range == rng {
+			return &r.Ranges[i]
+		}
+	}
+	r.Ranges = append(r.Ranges, RangeResult{Range: rng})
+	return &r.Ranges[len(r.Ranges)-1]
+}
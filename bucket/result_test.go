@@ -0,0 +1,99 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+type testSizedObject struct {
+	testObject
+	size int64
+}
+
+func (t *testSizedObject) Size() int64 {
+	return t.size
+}
+
+func TestResult(t *testing.T) {
+	ranges := []agerotate.Range{
+		{Age: 10 * time.Second, Interval: 0},
+		{Age: 100 * time.Second, Interval: 30 * time.Second},
+	}
+	objects := testBucketObjects{
+		&testSizedObject{testObject{age: 1 * time.Second}, 100},
+		&testSizedObject{testObject{age: 5 * time.Second}, 200},
+		&testSizedObject{testObject{age: 40 * time.Second}, 300},
+		&testSizedObject{testObject{age: 50 * time.Second}, 400},
+		&testFailObject{testObject{age: 60 * time.Second}},
+		&testObject{age: 90 * time.Second},
+		&testSizedObject{testObject{age: 200 * time.Second}, 500},
+	}
+
+	decisions, err := Plan(ranges, objects)
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	planned := decisions.Result()
+	result, err := Cleaner{ContinueOnError: true}.Apply(decisions)
+	if _, ok := err.(DeleteErrors); !ok {
+		t.Fatalf("Expected DeleteErrors, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		id       string
+		result   Result
+		expected []RangeResult
+	}{
+		{
+			id:     "Planned",
+			result: planned,
+			expected: []RangeResult{
+				{Range: &ranges[0], Kept: 2, KeptBytes: 300},
+				{Range: &ranges[1], Kept: 2, Deleted: 2, KeptBytes: 300, ReclaimedBytes: 400},
+				{Kept: 0, Deleted: 1, ReclaimedBytes: 500},
+			},
+		},
+		{
+			id:     "Applied",
+			result: result,
+			expected: []RangeResult{
+				{Range: &ranges[0], Kept: 2, KeptBytes: 300},
+				{Range: &ranges[1], Kept: 2, Deleted: 1, KeptBytes: 300, ReclaimedBytes: 400},
+				{Kept: 0, Deleted: 1, ReclaimedBytes: 500},
+			},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		if len(tc.result.Ranges) != len(tc.expected) {
+			t.Fatalf("Expected %d ranges, got %d", len(tc.expected), len(tc.result.Ranges))
+		}
+		var keptBytes, reclaimedBytes int64
+		for i, expected := range tc.expected {
+			got := tc.result.Ranges[i]
+			if (got.Range == nil) != (expected.Range == nil) || (got.Range != nil && *got.Range != *expected.Range) {
+				t.Fatalf("Range %d: expected range %v, got %v", i, expected.Range, got.Range)
+			}
+			got.Range, expected.Range = nil, nil
+			if got != expected {
+				t.Fatalf("Range %d: expected %+v, got %+v", i, expected, got)
+			}
+			keptBytes += got.KeptBytes
+			reclaimedBytes += got.ReclaimedBytes
+		}
+		if tc.result.KeptBytes != keptBytes || tc.result.ReclaimedBytes != reclaimedBytes {
+			t.Fatalf("Expected totals %d and %d, got %d and %d", keptBytes, reclaimedBytes, tc.result.KeptBytes, tc.result.ReclaimedBytes)
+		}
+	}
+}
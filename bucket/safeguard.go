@@ -0,0 +1,77 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// LimitError is returned when a plan calls for more deletions than a Cleaner allows.
+type LimitError struct {
+	// Deletions is the number of deletions in the plan.
+	Deletions int
+	// Objects is the number of objects in the plan.
+	Objects int
+	// Limit describes the limit that was exceeded.
+	Limit string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("Refusing to delete %d of %d objects, limit is %s", e.Deletions, e.Objects, e.Limit)
+}
+
+// CheckLimits returns a *LimitError if decisions would delete more objects than MaxDelete or MaxDeletePercent allow.
+func (c Cleaner) CheckLimits(decisions Decisions) error {
+	deletions := 0
+	for _, d := range decisions {
+		if !d.Keep {
+			deletions++
+		}
+	}
+	if c.MaxDelete > 0 && deletions > c.MaxDelete {
+		return &LimitError{Deletions: deletions, Objects: len(decisions), Limit: strconv.Itoa(c.MaxDelete)}
+	}
+	if c.MaxDeletePercent > 0 && float64(deletions)*100 > c.MaxDeletePercent*float64(len(decisions)) {
+		return &LimitError{Deletions: deletions, Objects: len(decisions), Limit: fmt.Sprintf("%g%%", c.MaxDeletePercent)}
+	}
+	return nil
+}
+
+// enforceMinKeep changes the youngest deletions into keeps until at least minKeep objects are kept.
+func (d Decisions) enforceMinKeep(minKeep int) {
+	kept := 0
+	deletions := []int{}
+	for i := range d {
+		if d[i].Keep {
+			kept++
+		} else {
+			deletions = append(deletions, i)
+		}
+	}
+	if kept >= minKeep {
+		return
+	}
+
+	sort.SliceStable(deletions, func(i, j int) bool {
+		return d[deletions[i]].Object.Age() < d[deletions[j]].Object.Age()
+	})
+	for _, i := range deletions {
+		if kept >= minKeep {
+			break
+		}
+		d[i].Keep = true
+		d[i].Protected = true
+		d[i].Reason = fmt.Sprintf("kept to retain at least %d objects, otherwise %s", minKeep, d[i].Reason)
+		kept++
+	}
+}
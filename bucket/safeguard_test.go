@@ -0,0 +1,168 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+func TestMinKeep(t *testing.T) {
+	deleted := true
+	for _, tc := range []struct {
+		id        string
+		minKeep   int
+		objects   []time.Duration
+		expected  []bool
+		protected int
+	}{
+		{
+			id:       "No minimum",
+			minKeep:  0,
+			objects:  []time.Duration{50 * time.Second, 60 * time.Second, 70 * time.Second},
+			expected: []bool{deleted, deleted, deleted},
+		},
+		{
+			id:        "Keep youngest two",
+			minKeep:   2,
+			objects:   []time.Duration{70 * time.Second, 50 * time.Second, 60 * time.Second},
+			expected:  []bool{deleted, !deleted, !deleted},
+			protected: 2,
+		},
+		{
+			id:       "Ranges already keep enough",
+			minKeep:  2,
+			objects:  []time.Duration{1 * time.Second, 5 * time.Second, 60 * time.Second},
+			expected: []bool{!deleted, !deleted, deleted},
+		},
+		{
+			id:        "Top up from the ranges",
+			minKeep:   3,
+			objects:   []time.Duration{1 * time.Second, 5 * time.Second, 60 * time.Second, 70 * time.Second},
+			expected:  []bool{!deleted, !deleted, !deleted, deleted},
+			protected: 1,
+		},
+		{
+			id:        "Fewer objects than minimum",
+			minKeep:   10,
+			objects:   []time.Duration{60 * time.Second, 70 * time.Second},
+			expected:  []bool{!deleted, !deleted},
+			protected: 2,
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		ranges := []agerotate.Range{{Age: 10 * time.Second, Interval: 0}}
+		objs := make([]*testObject, len(tc.objects))
+		objects := testBucketObjects{}
+		for i := range tc.objects {
+			objs[i] = &testObject{age: tc.objects[i]}
+			objects = append(objects, objs[i])
+		}
+
+		decisions, err := Cleaner{MinKeep: tc.minKeep}.Plan(ranges, objects)
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		if err := Apply(decisions); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		for i := range tc.expected {
+			if tc.expected[i] != objs[i].deleted {
+				got := make([]bool, len(objs))
+				for j := range objs {
+					got[j] = objs[j].deleted
+				}
+				t.Fatalf("Expected deleted: %v, got %v", tc.expected, got)
+			}
+		}
+		if protected := decisions.Protected(); len(protected) != tc.protected {
+			t.Fatalf("Expected %d protected, got %d", tc.protected, len(protected))
+		}
+	}
+}
+
+func TestMaxDelete(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		cleaner     Cleaner
+		deletions   int
+		expectedErr string
+	}{
+		{
+			id:        "No limits",
+			cleaner:   Cleaner{},
+			deletions: 8,
+		},
+		{
+			id:        "At count limit",
+			cleaner:   Cleaner{MaxDelete: 8},
+			deletions: 8,
+		},
+		{
+			id:          "Over count limit",
+			cleaner:     Cleaner{MaxDelete: 7},
+			deletions:   8,
+			expectedErr: "Refusing to delete 8 of 10 objects, limit is 7",
+		},
+		{
+			id:        "At percent limit",
+			cleaner:   Cleaner{MaxDeletePercent: 80},
+			deletions: 8,
+		},
+		{
+			id:          "Over percent limit",
+			cleaner:     Cleaner{MaxDeletePercent: 75},
+			deletions:   8,
+			expectedErr: "Refusing to delete 8 of 10 objects, limit is 75%",
+		},
+		{
+			id:          "Count checked before percent",
+			cleaner:     Cleaner{MaxDelete: 1, MaxDeletePercent: 10},
+			deletions:   8,
+			expectedErr: "Refusing to delete 8 of 10 objects, limit is 1",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		objs := make([]*testObject, 10)
+		decisions := Decisions{}
+		for i := range objs {
+			objs[i] = &testObject{age: time.Duration(i) * time.Second}
+			decisions = append(decisions, Decision{Object: objs[i], Keep: i >= tc.deletions})
+		}
+
+		result, err := tc.cleaner.Apply(decisions)
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("Expected no error, got %q", err)
+			}
+			if result.Deleted != tc.deletions {
+				t.Fatalf("Expected %d deleted, got %d", tc.deletions, result.Deleted)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("Expected error %q, got nil", tc.expectedErr)
+		}
+		if _, ok := err.(*LimitError); !ok {
+			t.Fatalf("Expected *LimitError, got %T", err)
+		}
+		if err.Error() != tc.expectedErr {
+			t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+		}
+		for _, o := range objs {
+			if o.deleted {
+				t.Fatalf("Expected nothing deleted, %v was", o.ID())
+			}
+		}
+	}
+}
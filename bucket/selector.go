@@ -0,0 +1,184 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// Selectors maps names to the Selectors that can be chosen by name, such as from a config file.
+var Selectors = map[string]agerotate.Selector{
+	KeepYoungest{}.String(): KeepYoungest{},
+	KeepOldest{}.String():   KeepOldest{},
+	KeepMidpoint{}.String(): KeepMidpoint{},
+	KeepLargest{}.String():  KeepLargest{},
+}
+
+// IsSlotSelector reports whether s keeps one object per Interval-long slot aligned to the timeline's anchor, as KeepMidpoint and KeepLargest do. Only these honor the anchoring of Aligned ranges; any other Selector on an Aligned range replaces the alignment.
+func IsSlotSelector(s agerotate.Selector) bool {
+	switch s.(type) {
+	case KeepMidpoint, KeepLargest, alignedSelector:
+		return true
+	}
+	return false
+}
+
+// KeepYoungest is the default Selector. The youngest object is always retained. For each object thereafter, if the age of the object is less than the age of the last retained object plus Interval, the newer object is deleted. If the next object is older than the age of the last retained object plus Interval, the newer object is retained and processing continues.
+type KeepYoungest struct{}
+
+func (KeepYoungest) String() string { return "youngest" }
+
+// Select implements agerotate.Selector.
+func (KeepYoungest) Select(r agerotate.Range, objects []agerotate.Object, t agerotate.Timeline) []agerotate.Selection {
+	if len(objects) == 0 {
+		return nil
+	}
+	selections := make([]agerotate.Selection, len(objects))
+	base := objects[0]
+	baseAge := base.Age()
+	selections[0] = agerotate.Selection{Keep: true, Reason: "youngest object in range"}
+	for i, o := range objects[1:] {
+		oAge := o.Age()
+		if gap := oAge - baseAge; gap < r.Interval {
+			selections[i+1] = agerotate.Selection{Reason: fmt.Sprintf("only %s older than the last kept object, interval is %s", agerotate.FormatDuration(gap), agerotate.FormatDuration(r.Interval)), Neighbor: base}
+		} else {
+			selections[i+1] = agerotate.Selection{Keep: true, Reason: fmt.Sprintf("%s older than the last kept object, interval is %s", agerotate.FormatDuration(gap), agerotate.FormatDuration(r.Interval)), Neighbor: base}
+			base, baseAge = o, oAge
+		}
+	}
+	return selections
+}
+
+// KeepOldest works like KeepYoungest from the other end of the range. The oldest object is always retained and each younger object is retained only if it's at least Interval younger than the last object retained.
+type KeepOldest struct{}
+
+func (KeepOldest) String() string { return "oldest" }
+
+// Select implements agerotate.Selector.
+func (KeepOldest) Select(r agerotate.Range, objects []agerotate.Object, t agerotate.Timeline) []agerotate.Selection {
+	if len(objects) == 0 {
+		return nil
+	}
+	selections := make([]agerotate.Selection, len(objects))
+	last := len(objects) - 1
+	base := objects[last]
+	baseAge := base.Age()
+	selections[last] = agerotate.Selection{Keep: true, Reason: "oldest object in range"}
+	for i := last - 1; i >= 0; i-- {
+		oAge := objects[i].Age()
+		if gap := baseAge - oAge; gap < r.Interval {
+			selections[i] = agerotate.Selection{Reason: fmt.Sprintf("only %s younger than the last kept object, interval is %s", agerotate.FormatDuration(gap), agerotate.FormatDuration(r.Interval)), Neighbor: base}
+		} else {
+			selections[i] = agerotate.Selection{Keep: true, Reason: fmt.Sprintf("%s younger than the last kept object, interval is %s", agerotate.FormatDuration(gap), agerotate.FormatDuration(r.Interval)), Neighbor: base}
+			base, baseAge = objects[i], oAge
+		}
+	}
+	return selections
+}
+
+// KeepMidpoint divides time into Interval-long slots aligned to the timeline's anchor and keeps the object created closest to the middle of each slot. Ties go to the older object.
+type KeepMidpoint struct{}
+
+func (KeepMidpoint) String() string { return "midpoint" }
+
+// Select implements agerotate.Selector.
+func (KeepMidpoint) Select(r agerotate.Range, objects []agerotate.Object, t agerotate.Timeline) []agerotate.Selection {
+	distance := func(o agerotate.Object) time.Duration {
+		mid := t.SlotStart(o.Age(), r.Interval).Add(r.Interval / 2)
+		d := t.At(o.Age()).Sub(mid)
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	return selectPerSlot(r, objects, t, "closest to the middle of", func(candidate, best agerotate.Object) bool {
+		return distance(candidate) < distance(best)
+	})
+}
+
+// KeepLargest divides time into Interval-long slots aligned to the timeline's anchor and keeps the largest object in each slot, as reported by agerotate.Sizer. Ties go to the older object.
+type KeepLargest struct{}
+
+func (KeepLargest) String() string { return "largest" }
+
+// Select implements agerotate.Selector.
+func (KeepLargest) Select(r agerotate.Range, objects []agerotate.Object, t agerotate.Timeline) []agerotate.Selection {
+	return selectPerSlot(r, objects, t, "largest object in", func(candidate, best agerotate.Object) bool {
+		return agerotate.SizeOf(candidate) > agerotate.SizeOf(best)
+	})
+}
+
+// alignedSelector is used for Aligned ranges. It keeps the oldest object created within each Interval-long slot aligned to the timeline's anchor. Keeping the oldest means the object kept for a slot doesn't change as younger objects age into the range.
+type alignedSelector struct{}
+
+func (alignedSelector) String() string { return "aligned" }
+
+func (alignedSelector) Select(r agerotate.Range, objects []agerotate.Object, t agerotate.Timeline) []agerotate.Selection {
+	return selectPerSlot(r, objects, t, "oldest object in", func(candidate, best agerotate.Object) bool {
+		return false
+	})
+}
+
+// periodSelector is used for ranges with a Period. It keeps the youngest object created within each calendar period.
+type periodSelector struct{}
+
+func (periodSelector) String() string { return "calendar" }
+
+func (periodSelector) Select(r agerotate.Range, objects []agerotate.Object, t agerotate.Timeline) []agerotate.Selection {
+	selections := make([]agerotate.Selection, len(objects))
+	kept := map[int64]agerotate.Object{}
+	for i, o := range objects {
+		start := r.Period.Start(t.At(o.Age()))
+		if k, ok := kept[start.Unix()]; ok {
+			selections[i] = agerotate.Selection{Reason: fmt.Sprintf("a younger object was kept for the %s starting %s", r.Period, start.Format("2006-01-02")), Neighbor: k}
+		} else {
+			kept[start.Unix()] = o
+			selections[i] = agerotate.Selection{Keep: true, Reason: fmt.Sprintf("last object of the %s starting %s", r.Period, start.Format("2006-01-02"))}
+		}
+	}
+	return selections
+}
+
+// selectPerSlot keeps one object in each Interval-long slot aligned to the timeline's anchor. Objects are considered oldest first and an object replaces the current choice for its slot only if better reports that it should. If Interval isn't positive every object is kept.
+func selectPerSlot(r agerotate.Range, objects []agerotate.Object, t agerotate.Timeline, rule string, better func(candidate, best agerotate.Object) bool) []agerotate.Selection {
+	if len(objects) == 0 {
+		return nil
+	}
+	selections := make([]agerotate.Selection, len(objects))
+	if r.Interval <= 0 {
+		for i := range selections {
+			selections[i] = agerotate.Selection{Keep: true, Reason: "interval is 0s, every object is kept"}
+		}
+		return selections
+	}
+
+	best := map[int64]int{}
+	starts := make([]time.Time, len(objects))
+	for i := len(objects) - 1; i >= 0; i-- {
+		starts[i] = t.SlotStart(objects[i].Age(), r.Interval)
+		key := starts[i].UnixNano()
+		if b, ok := best[key]; !ok || better(objects[i], objects[b]) {
+			best[key] = i
+		}
+	}
+	for i := range objects {
+		slot := fmt.Sprintf("the %s slot starting %s", agerotate.FormatDuration(r.Interval), starts[i].Format(time.RFC3339))
+		if best[starts[i].UnixNano()] == i {
+			selections[i] = agerotate.Selection{Keep: true, Reason: fmt.Sprintf("%s %s", rule, slot)}
+		} else {
+			selections[i] = agerotate.Selection{Reason: fmt.Sprintf("another object was kept for %s", slot), Neighbor: objects[best[starts[i].UnixNano()]]}
+		}
+	}
+	return selections
+}
@@ -0,0 +1,164 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package bucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+func TestSelectors(t *testing.T) {
+	timeline := agerotate.Timeline{
+		Now:      time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC),
+		Location: time.UTC,
+		Anchor:   time.Unix(0, 0),
+	}
+	sized := func(age time.Duration, size int64) agerotate.Object {
+		return &testSizedObject{testObject{age: age}, size}
+	}
+
+	for _, tc := range []struct {
+		id       string
+		selector agerotate.Selector
+		interval time.Duration
+		objects  []agerotate.Object
+		expected []bool
+		// neighbors holds the index of each selection's expected Neighbor, or -1 for none.
+		neighbors []int
+		// reason, if set, is the expected Reason of the second selection.
+		reason string
+	}{
+		{
+			id:        "Youngest, 31s interval",
+			selector:  KeepYoungest{},
+			interval:  31 * time.Second,
+			objects:   []agerotate.Object{sized(0, 0), sized(30*time.Second, 0), sized(60*time.Second, 0), sized(90*time.Second, 0)},
+			expected:  []bool{true, false, true, false},
+			neighbors: []int{-1, 0, 0, 2},
+			reason:    "only 30s older than the last kept object, interval is 31s",
+		},
+		{
+			id:        "Oldest, 31s interval",
+			selector:  KeepOldest{},
+			interval:  31 * time.Second,
+			objects:   []agerotate.Object{sized(0, 0), sized(30*time.Second, 0), sized(60*time.Second, 0), sized(90*time.Second, 0)},
+			expected:  []bool{false, true, false, true},
+			neighbors: []int{1, 3, 3, -1},
+		},
+		{
+			id:        "Oldest, 0s interval",
+			selector:  KeepOldest{},
+			interval:  0,
+			objects:   []agerotate.Object{sized(0, 0), sized(30*time.Second, 0)},
+			expected:  []bool{true, true},
+			neighbors: []int{1, -1},
+		},
+		{
+			id:       "Midpoint",
+			selector: KeepMidpoint{},
+			interval: 6 * time.Hour,
+			objects: []agerotate.Object{
+				sized(1*time.Hour, 0),
+				sized(150*time.Minute, 0),
+				sized(210*time.Minute, 0),
+				sized(5*time.Hour, 0),
+				sized(7*time.Hour, 0),
+			},
+			expected:  []bool{false, false, true, false, true},
+			neighbors: []int{2, 2, -1, 2, -1},
+		},
+		{
+			id:       "Largest",
+			selector: KeepLargest{},
+			interval: 6 * time.Hour,
+			objects: []agerotate.Object{
+				sized(1*time.Hour, 10),
+				sized(2*time.Hour, 30),
+				sized(3*time.Hour, 30),
+				sized(7*time.Hour, 1),
+			},
+			expected:  []bool{false, false, true, true},
+			neighbors: []int{2, 2, -1, -1},
+			reason:    "another object was kept for the 6h slot starting 2026-10-17T06:00:00Z",
+		},
+		{
+			id:        "Largest, 0s interval",
+			selector:  KeepLargest{},
+			interval:  0,
+			objects:   []agerotate.Object{sized(1*time.Hour, 10), sized(2*time.Hour, 30)},
+			expected:  []bool{true, true},
+			neighbors: []int{-1, -1},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		r := agerotate.Range{Age: 24 * time.Hour, Interval: tc.interval, Selector: tc.selector}
+		selections := tc.selector.Select(r, tc.objects, timeline)
+		if len(selections) != len(tc.expected) {
+			t.Fatalf("Expected %d selections, got %d", len(tc.expected), len(selections))
+		}
+		if tc.reason != "" && selections[1].Reason != tc.reason {
+			t.Fatalf("Expected reason %q, got %q", tc.reason, selections[1].Reason)
+		}
+		for i := range tc.expected {
+			if selections[i].Keep != tc.expected[i] {
+				t.Fatalf("Expected kept %v, got %v", tc.expected, selections)
+			}
+			if selections[i].Reason == "" {
+				t.Fatalf("Selection %d has no reason", i)
+			}
+			var neighbor agerotate.Object
+			if tc.neighbors[i] >= 0 {
+				neighbor = tc.objects[tc.neighbors[i]]
+			}
+			if selections[i].Neighbor != neighbor {
+				t.Fatalf("Expected selection %d to have neighbor %v, got %v", i, neighbor, selections[i].Neighbor)
+			}
+		}
+	}
+}
+
+func TestSelectorsCalledDirectly(t *testing.T) {
+	r := agerotate.Range{Age: 24 * time.Hour, Interval: 6 * time.Hour}
+	objects := []agerotate.Object{&testObject{age: time.Hour}, &testObject{age: 2 * time.Hour}}
+	for name, selector := range Selectors {
+		t.Logf("Testing case %q", name)
+		if selections := selector.Select(r, nil, agerotate.Timeline{}); selections != nil {
+			t.Fatalf("Expected nil selections for no objects, got %v", selections)
+		}
+		// The zero Timeline has no Location, which must be treated as time.Local.
+		selections := selector.Select(r, objects, agerotate.Timeline{})
+		if len(selections) != len(objects) {
+			t.Fatalf("Expected %d selections, got %d", len(objects), len(selections))
+		}
+	}
+}
+
+func TestPlanUsesRangeSelector(t *testing.T) {
+	ranges := []agerotate.Range{{Age: 100 * time.Second, Interval: 31 * time.Second, Selector: KeepOldest{}}}
+	objs := []*testObject{{age: 0}, {age: 30 * time.Second}, {age: 60 * time.Second}, {age: 90 * time.Second}}
+	objects := testBucketObjects{}
+	for _, o := range objs {
+		objects = append(objects, o)
+	}
+
+	decisions, err := Plan(ranges, objects)
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	expected := []bool{false, true, false, true}
+	for i, d := range decisions {
+		if d.Keep != expected[i] {
+			t.Fatalf("Decision %d: expected keep %v, got %v (%s)", i, expected[i], d.Keep, d.Reason)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package agerotate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxDuration is the longest time.Duration.
+const maxDuration = time.Duration(1<<63 - 1)
+
+// Lengths of the units ParseDuration accepts beyond those of time.ParseDuration. Months and years have a fixed length and don't follow the calendar; use a Period for calendar-based retention.
+const (
+	DurationDay   = 24 * time.Hour
+	DurationWeek  = 7 * DurationDay
+	DurationMonth = 30 * DurationDay
+	DurationYear  = 365 * DurationDay
+)
+
+// durationUnits maps the extra units ParseDuration accepts to their lengths.
+var durationUnits = map[string]time.Duration{
+	"d":  DurationDay,
+	"w":  DurationWeek,
+	"mo": DurationMonth,
+	"y":  DurationYear,
+}
+
+// formatUnits are the extra units FormatDuration may use, longest first.
+var formatUnits = []struct {
+	name   string
+	length time.Duration
+}{
+	{"y", DurationYear},
+	{"mo", DurationMonth},
+	{"w", DurationWeek},
+	{"d", DurationDay},
+}
+
+// ParseDuration parses a duration like time.ParseDuration but also accepts the units d (day), w (week), mo (month of 30 days) and y (year of 365 days), such as "6mo" or "1d12h". A string using none of them is parsed by time.ParseDuration unchanged.
+func ParseDuration(s string) (time.Duration, error) {
+	rest := s
+	negative := false
+	if rest != "" && (rest[0] == '-' || rest[0] == '+') {
+		negative = rest[0] == '-'
+		rest = rest[1:]
+	}
+
+	type segment struct{ number, unit string }
+	var segments []segment
+	extended := false
+	for rest != "" {
+		i := 0
+		for i < len(rest) && (rest[i] == '.' || rest[i] >= '0' && rest[i] <= '9') {
+			i++
+		}
+		j := i
+		for j < len(rest) && rest[j] != '.' && (rest[j] < '0' || rest[j] > '9') {
+			j++
+		}
+		if i == 0 || j == i {
+			if extended {
+				return 0, fmt.Errorf("Invalid duration %q", s)
+			}
+			// Let time.ParseDuration report the error, or accept a bare "0".
+			return time.ParseDuration(s)
+		}
+		seg := segment{rest[:i], rest[i:j]}
+		if _, ok := durationUnits[seg.unit]; ok {
+			extended = true
+		}
+		segments = append(segments, seg)
+		rest = rest[j:]
+	}
+	if !extended {
+		return time.ParseDuration(s)
+	}
+
+	var total time.Duration
+	for _, seg := range segments {
+		var v time.Duration
+		var err error
+		if length, ok := durationUnits[seg.unit]; ok {
+			v, err = scaleDuration(seg.number, length)
+			if err == errDurationTooLong {
+				return 0, fmt.Errorf("Duration %q is too long", s)
+			}
+			if err != nil {
+				return 0, fmt.Errorf("Invalid duration %q: %v", s, err)
+			}
+		} else if v, err = time.ParseDuration(seg.number + seg.unit); err != nil {
+			return 0, fmt.Errorf("Invalid duration %q", s)
+		}
+		if v > maxDuration-total {
+			return 0, fmt.Errorf("Duration %q is too long", s)
+		}
+		total += v
+	}
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// errDurationTooLong is returned by scaleDuration when the result doesn't fit in a time.Duration.
+var errDurationTooLong = errors.New("too long")
+
+// scaleDuration returns number, a decimal such as "1.5", times length.
+func scaleDuration(number string, length time.Duration) (time.Duration, error) {
+	whole, frac := number, ""
+	if dot := strings.IndexByte(number, '.'); dot >= 0 {
+		whole, frac = number[:dot], number[dot+1:]
+	}
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("missing number")
+	}
+	var v time.Duration
+	for _, c := range whole {
+		if v > (maxDuration-length*time.Duration(c-'0'))/10 {
+			return 0, errDurationTooLong
+		}
+		v = v*10 + length*time.Duration(c-'0')
+	}
+	scale := length
+	for _, c := range frac {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid number %q", number)
+		}
+		scale /= 10
+		v += scale * time.Duration(c-'0')
+	}
+	return v, nil
+}
+
+// FormatDuration renders d the way ParseDuration reads it, using the longest of y, mo, w and d that divides d evenly, such as "6mo" for 4320h. Durations that aren't a whole number of days have days split off, such as "1d12h", and zero minutes and seconds are dropped, such as "1h30m" for 1h30m0s.
+func FormatDuration(d time.Duration) string {
+	if d < 0 && d != -maxDuration-1 {
+		return "-" + FormatDuration(-d)
+	}
+	for _, u := range formatUnits {
+		if d >= u.length && d%u.length == 0 {
+			return fmt.Sprintf("%d%s", d/u.length, u.name)
+		}
+	}
+	if d > DurationDay {
+		return fmt.Sprintf("%dd%s", d/DurationDay, trimDuration(d%DurationDay))
+	}
+	return trimDuration(d)
+}
+
+// trimDuration is time.Duration.String without trailing zero minutes and seconds.
+func trimDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package agerotate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		input       string
+		expected    time.Duration
+		expectedErr string
+	}{
+		{id: "Hours", input: "4320h", expected: 4320 * time.Hour},
+		{id: "Go units", input: "1h30m10.5s", expected: time.Hour + 30*time.Minute + 10500*time.Millisecond},
+		{id: "Zero", input: "0", expected: 0},
+		{id: "Days", input: "3d", expected: 72 * time.Hour},
+		{id: "Weeks", input: "2w", expected: 336 * time.Hour},
+		{id: "Months", input: "6mo", expected: 4320 * time.Hour},
+		{id: "Years", input: "1y", expected: 8760 * time.Hour},
+		{id: "Mixed", input: "1y2mo3w4d5h6m", expected: 8760*time.Hour + 1440*time.Hour + 504*time.Hour + 96*time.Hour + 5*time.Hour + 6*time.Minute},
+		{id: "Fraction", input: "1.5d", expected: 36 * time.Hour},
+		{id: "Leading dot", input: ".5w", expected: 84 * time.Hour},
+		{id: "Negative", input: "-2d", expected: -48 * time.Hour},
+		{id: "Plus", input: "+1d", expected: 24 * time.Hour},
+		{id: "Unknown unit", input: "3x", expectedErr: `time: unknown unit "x" in duration "3x"`},
+		{id: "Missing unit after day", input: "1d2", expectedErr: `Invalid duration "1d2"`},
+		{id: "Bad number", input: "1.2.3d", expectedErr: `Invalid duration "1.2.3d": invalid number "1.2.3"`},
+		{id: "Bad Go segment", input: "1d1.2.3h", expectedErr: `Invalid duration "1d1.2.3h"`},
+		{id: "Too long", input: "300y", expectedErr: `Duration "300y" is too long`},
+		{id: "Far too long", input: "99999999y", expectedErr: `Duration "99999999y" is too long`},
+		{id: "Sum too long", input: "290y2000000h", expectedErr: `Duration "290y2000000h" is too long`},
+		{id: "Empty", input: "", expectedErr: `time: invalid duration ""`},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		got, err := ParseDuration(tc.input)
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected error %q, got %v", tc.expectedErr, got)
+			}
+			if err.Error() != tc.expectedErr {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if got != tc.expected {
+			t.Fatalf("Expected %v, got %v", tc.expected, got)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for _, tc := range []struct {
+		id       string
+		input    time.Duration
+		expected string
+	}{
+		{id: "Zero", input: 0, expected: "0s"},
+		{id: "Seconds", input: 1500 * time.Millisecond, expected: "1.5s"},
+		{id: "Minutes", input: 10 * time.Minute, expected: "10m"},
+		{id: "Hours and minutes", input: 90 * time.Minute, expected: "1h30m"},
+		{id: "Hours", input: 12 * time.Hour, expected: "12h"},
+		{id: "Day", input: 24 * time.Hour, expected: "1d"},
+		{id: "Days and hours", input: 36 * time.Hour, expected: "1d12h"},
+		{id: "Days", input: 72 * time.Hour, expected: "3d"},
+		{id: "Weeks", input: 1344 * time.Hour, expected: "8w"},
+		{id: "Months", input: 4320 * time.Hour, expected: "6mo"},
+		{id: "Leap year", input: 8784 * time.Hour, expected: "366d"},
+		{id: "Years", input: 2 * 8760 * time.Hour, expected: "2y"},
+		{id: "Negative", input: -48 * time.Hour, expected: "-2d"},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		got := FormatDuration(tc.input)
+		if got != tc.expected {
+			t.Fatalf("Expected %q, got %q", tc.expected, got)
+		}
+		back, err := ParseDuration(got)
+		if err != nil || back != tc.input {
+			t.Fatalf("Expected %q to parse back to %v, got %v (%v)", got, tc.input, back, err)
+		}
+	}
+}
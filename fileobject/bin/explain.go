@@ -0,0 +1,154 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+	"github.com/AgentZombie/agerotate/bucket"
+	"github.com/AgentZombie/agerotate/fileobject"
+	"github.com/AgentZombie/agerotate/fileobject/config"
+)
+
+// explainMain runs the explain subcommand, which plans a cleanup without deleting anything and prints how the decision for each path given was made by each job matching it.
+func explainMain(args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to file rotation config.")
+	configDir := flags.String("config-dir", "", "Directory of configs to load instead of -config. Each .conf or .json file is a job named after the file unless it has JOB lines.")
+	fieldSep := flags.String("fieldsep", ":", "Field separator for range lines.")
+	asOf := flags.String("as-of", "", "Explain the decisions as if it were this time: RFC 3339, a date like 2026-01-31, or an offset from now like +720h.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s explain -config path [-as-of time] file...\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	jobs := loadJobs(*configPath, *configDir, *fieldSep)
+	explained := map[string]bool{}
+	failed := false
+	for _, job := range jobs {
+		if *asOf != "" {
+			setAsOf(*asOf, &job)
+		}
+		if err := explainJob(job, flags.Args(), explained); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError planning cleanup: %v\n", jobPrefix(job), err)
+			failed = true
+		}
+	}
+
+	unexplained := 0
+	for _, path := range flags.Args() {
+		if explained[path] {
+			continue
+		}
+		unexplained++
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fmt.Printf("%s: does not exist\n", path)
+		} else if job, e := excludedBy(jobs, path); e != nil && job.Name != "" {
+			fmt.Printf("%s: excluded by %q in job %q\n", path, e, job.Name)
+		} else if e != nil {
+			fmt.Printf("%s: excluded by %q\n", path, e)
+		} else {
+			fmt.Printf("%s: not matched by any PATHGLOB\n", path)
+		}
+	}
+	if unexplained > 0 || failed {
+		os.Exit(1)
+	}
+}
+
+// excludedBy returns the first job with an EXCLUDE or EXCLUDEREGEX matching path and the exclude that matched, or a nil exclude if there's none.
+func excludedBy(jobs []config.Job, path string) (config.Job, *fileobject.Exclude) {
+	for _, job := range jobs {
+		for _, p := range []string{path, absPath(path)} {
+			if e := job.Files.Excluded(p); e != nil {
+				return job, e
+			}
+		}
+	}
+	return config.Job{}, nil
+}
+
+// explainJob prints the decision job makes for each of paths it matches and records them in explained. An error planning the job is returned so that the other jobs can still be explained.
+func explainJob(job config.Job, paths []string, explained map[string]bool) error {
+	decisions, err := job.Cleaner.Plan(job.Ranges, job.Files)
+	if err != nil {
+		return err
+	}
+
+	now := job.Cleaner.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	location := job.Cleaner.Location
+	if location == nil {
+		location = time.Local
+	}
+	for _, path := range paths {
+		d, ok := findDecision(decisions, path)
+		if !ok {
+			continue
+		}
+		explained[path] = true
+
+		action := "delete"
+		if d.Keep {
+			action = "keep"
+		}
+		age := d.Object.Age()
+		fmt.Printf("%s\n", d.Object.ID())
+		if job.Name != "" {
+			fmt.Printf("  Job:      %s\n", job.Name)
+		}
+		fmt.Printf("  Age:      %s (created %s)\n", agerotate.FormatDuration(age.Round(time.Second)), now.Add(-age).In(location).Format(time.RFC3339))
+		fmt.Printf("  Range:    %s\n", rangeDesc(d.Range))
+		fmt.Printf("  Decision: %s\n", action)
+		if d.Neighbor != nil {
+			fmt.Printf("  Compared: %s (age %s)\n", d.Neighbor.ID(), agerotate.FormatDuration(d.Neighbor.Age().Round(time.Second)))
+		}
+		fmt.Printf("  Rule:     %s\n", d.Reason)
+	}
+	return nil
+}
+
+// findDecision returns the decision for path. The path may be relative to the working directory or absolute, whichever form the IDs from PATHGLOB take.
+func findDecision(decisions bucket.Decisions, path string) (bucket.Decision, bool) {
+	ids := []string{filepath.Clean(path), absPath(path)}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, absPath(path)); err == nil {
+			ids = append(ids, rel)
+		}
+	}
+	for _, id := range ids {
+		if d, ok := decisions.Find(id); ok {
+			return d, true
+		}
+	}
+	return bucket.Decision{}, false
+}
+
+// absPath returns the absolute form of path so that paths given on the command line match those found by PATHGLOB, or path unchanged if that fails.
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
@@ -11,23 +11,46 @@ THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLI
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/AgentZombie/agerotate"
 	"github.com/AgentZombie/agerotate/bucket"
 	"github.com/AgentZombie/agerotate/fileobject/config"
 )
 
 var (
 	ConfigPath = flag.String("config", "", "Path to file rotation config.")
+	ConfigDir  = flag.String("config-dir", "", "Directory of configs to load instead of -config. Each .conf or .json file is a job named after the file unless it has JOB lines.")
+	DryRun     = flag.Bool("dry-run", false, "Print which files would be kept and deleted without deleting anything.")
 	FieldSep   = flag.String("fieldsep", ":", "Field separator for range lines.")
+	KeepGoing  = flag.Bool("keep-going", false, "Keep deleting after a failure and report every failure at the end.")
 	ShowFormat = flag.Bool("showfmt", false, "Take no action, just print the config format.")
+	Summary    = flag.Bool("summary", false, "Print the files and bytes kept and deleted in each range after cleanup.")
+	Workers    = flag.Int("workers", 1, "Number of files to delete at once.")
+	Timeout    = flag.Duration("timeout", 0, "Stop cleanly between deletes once this much time has passed. Zero means no limit.")
+	AsOf       = flag.String("as-of", "", "With -dry-run, plan as if it were this time: RFC 3339, a date like 2026-01-31, or an offset from now like +720h.")
 )
 
+// ExitLimit is the exit status when nothing was deleted because the run would have deleted more files than MAXDELETE or MAXDELETEPERCENT allow.
+const ExitLimit = 3
+
+// ExitWarnings is the exit status of lint -strict when the config has warnings.
+const ExitWarnings = 4
+
 func errorExit(format string, a ...interface{}) {
+	exitWith(-1, format, a...)
+}
+
+func exitWith(status int, format string, a ...interface{}) {
 	fmt.Fprintf(os.Stderr, format, a...)
-	os.Exit(-1)
+	os.Exit(status)
 }
 
 func showFormat() {
@@ -37,13 +60,59 @@ directive per line. For each line, leading and trailing whitespace is ignored.
 Any text followed by # is ignored, including the #. Blank lines and lines 
 composed of only whitespace and/or characters prefixed by # are ignored.
 
-There are two configuration directives: PATHGLOB and RANGE. PATHGLOB and RANGE
-need not be capitalized. All configuration directives are followed by %s, and
-then one or more values separated by %s. The separator can be changed with the
-fieldsep command line flag.
+The configuration directives are JOB, INCLUDE, DEFINE, PATHGLOB, EXCLUDE,
+EXCLUDEREGEX, RANGE, DAILY, WEEKLY, MONTHLY, YEARLY, TIMEZONE, ALIGN, SELECT,
+NAMETIME, NAMEREGEX, NAMEUNMATCHED, KEEPLAST, MINKEEP, MAXDELETE,
+MAXDELETEPERCENT, MAXBYTES, FOLLOWSYMLINKS, MAXDEPTH and REMOVEEMPTYDIRS.
+Directives need not be capitalized. All configuration directives are followed
+by %s, and then one or more values separated by %s. The separator can be
+changed with the fieldsep command line flag.
 
 PATHGLOB specifies a filesystem glob to select files for rotation. The PATHGLOB
-line is required, can appear anywhere in the file, and must appear only once.
+line is required, can appear anywhere in the file (or job), and must appear
+only once.
+
+A ** element in PATHGLOB matches any number of directories, including none,
+so /backups/**/*.tar.gz matches archives anywhere below /backups. Symlinked
+directories are skipped unless FOLLOWSYMLINKS is true, and each directory is
+walked once so symlink loops are harmless. MAXDEPTH takes a number and limits
+how many directories below the part of PATHGLOB without wildcards are walked.
+Both require a ** element. REMOVEEMPTYDIRS, if true, removes the directories
+left empty by deleting files, stopping at the part of PATHGLOB without
+wildcards.
+
+EXCLUDE and EXCLUDEREGEX leave out files that PATHGLOB matches, which are then
+never considered or deleted. Each may be given any number of times. EXCLUDE
+takes a glob: without a path separator it's matched against the file name and
+the name of each directory above it, so *.partial leaves out partial files and
+keep leaves out everything under a directory named keep, and with a separator
+it's matched against the whole path and each directory above it. Only the
+directories below the part of PATHGLOB without wildcards are checked, so the
+directories PATHGLOB names can't exclude every file. EXCLUDEREGEX takes a
+regular expression that leaves out any path it matches, such as \.partial$.
+
+A config can hold several rotation jobs, each starting with a JOB line that
+has a unique name, such as JOB%sdumps. Every other directive then belongs to the
+job above it, and each job needs its own PATHGLOB and ranges. Jobs run one
+after another and a failure in one doesn't stop the rest. A config without JOB
+lines is a single job.
+
+INCLUDE takes a path or glob and reads each matching file, in name order, as if
+its lines appeared in place of the INCLUDE line. Relative paths are resolved
+against the directory of the config holding the INCLUDE line. A glob matching
+nothing is ignored but a missing path is an error, as is a file that includes
+itself. Directives that may appear only once, such as MINKEEP, can be given
+again after the INCLUDE line to override an included default. Errors in an
+included file name the file.
+
+Any value may refer to a variable as ${NAME}. The variable comes from a DEFINE
+line earlier in the config, such as DEFINE%sROOT%s/srv/backup, or else from the
+environment. A variable that's defined nowhere is an error. $$ stands for a
+single $, and a $ not followed by { or $ is left alone. DEFINE values may use
+variables defined before them, and each variable may be defined only once.
+
+The -config-dir flag loads every .conf and .json file in a directory instead
+of -config. Each file without JOB lines is a job named after the file.
 
 RANGE identifies a set of files for rotation by their age. Each RANGE line has
 exactly two values: Age and Interval. Files with mtimes younger than Age but
@@ -62,22 +131,278 @@ An Interval of 0 is valid and causes all files in the range to be retained.
 It's often a good idea for the first RANGE to have an Interval of 0 so all
 of the most recent files are kept.
 
+By default the files kept depend on when filerotate runs, since each range
+measures Interval from its youngest file. ALIGN makes selection stable from
+run to run: time is divided into Interval-long slots aligned to a fixed anchor
+and the oldest file in each slot is kept. ALIGN takes one value, either
+"epoch" to align to the Unix epoch or a date such as 2026-01-01 to align to
+midnight of that date in TIMEZONE. It applies to every RANGE line.
+
+SELECT chooses which file is kept from each interval of a RANGE line. It takes
+one value: youngest (the default), oldest, midpoint for the file nearest the
+middle of the interval, or largest. It applies to every RANGE line. midpoint
+and largest work in aligned slots and can be combined with ALIGN, which sets
+their anchor. youngest and oldest would override ALIGN, so using either with
+ALIGN is an error.
+
+DAILY, WEEKLY, MONTHLY and YEARLY are calendar ranges. Each has a single
+value, Age, and is ordered among the RANGE lines by Age just like a RANGE
+line. Instead of an Interval, the last file of each local calendar day, ISO
+week (starting Monday), month or year within the range is kept. TIMEZONE
+optionally names the time zone used for calendar ranges, such as
+America/New_York. The system's local time zone is used by default.
+
+A file's age normally comes from its mtime. NAMETIME takes a strftime-style
+layout, such as db-%%Y%%m%%d-%%H%%M.sql.gz, and uses the timestamp in each file's
+name instead. The conversions %%Y, %%m, %%b (month name), %%d, %%H, %%M, %%S and
+%%s (Unix seconds) are supported and the layout may match anywhere in the name.
+NAMEREGEX is the alternative for names a layout can't describe: a regular
+expression with named groups year, month, day, hour, minute, second or unix.
+Only one of the two may be given, and the field separator may appear in either.
+Timestamps are read in TIMEZONE. NAMEUNMATCHED says what to do with files whose
+names don't match: skip leaves them alone (the default), mtime uses their
+mtime, and error stops filerotate before anything is deleted.
+
+KEEPLAST is optional and takes a single number. That many of the youngest
+files are kept regardless of their age and the RANGE lines only apply to the
+files that remain. A config may use KEEPLAST instead of RANGE lines.
+
+MAXBYTES is optional and takes a single size, such as 500G. Units K, M, G and
+T are powers of 1024 and may be followed by B. After the RANGE lines are
+applied, further files are deleted until the kept files fit within the size.
+The oldest range is thinned first, by repeatedly deleting every other kept
+file until one remains, then the next oldest range, and so on.
+
+MINKEEP is optional and takes a single number. No matter what the RANGE lines
+or MAXBYTES say, at least that many files are kept, preferring the youngest.
+It guards against deleting everything when new files stop arriving.
+
+MAXDELETE and MAXDELETEPERCENT are optional and each take a single number. If
+a run would delete more files than MAXDELETE, or more than MAXDELETEPERCENT
+percent of the matching files, nothing is deleted and filerotate exits with
+status %d.
+
 Times for Age and Interval are specified using the syntax specified in
-https://golang.org/pkg/time/#ParseDuration. Units larger than "h" are not
-available because calendar math is frought with peril.
+https://golang.org/pkg/time/#ParseDuration, such as 90m or 72h, plus the
+units d (day), w (week), mo (month) and y (year), such as 6mo or 1d12h. These
+have fixed lengths of 24h, 7d, 30d and 365d because calendar math is frought
+with peril. Use the calendar ranges when retention should follow calendar
+boundaries.
+
+A config whose name ends in .json is read as JSON instead. It holds a "jobs"
+list of objects. Each job's keys are the directives above in lower case with a
+string, number or boolean value, an optional "name" (required if there are several
+jobs), and a "ranges" list. Each range has an "age" and either an "interval"
+(0 if omitted) or a "period" of daily, weekly, monthly or yearly. A top-level
+"define" object sets variables like DEFINE lines. Errors and warnings name the
+key at fault, such as jobs[0].ranges[1]:
+  {"jobs": [{"pathglob": "/path/to/files/*.gz", "minkeep": 5,
+             "ranges": [{"age": "24h"}, {"age": "720h", "interval": "24h"},
+                        {"age": "8784h", "period": "monthly"}]}]}
 
 Sample Config:
   # RANGE:Age:Interval
   pathglob:/path/to/files/*.gz
   range:6h:0      # For the first six hours, keep all.
-  range:72h:4h	  # For files under 72 hours, keep one per 4 hours.
-  range:720h:24h  # For files under 30 days, keep one per day.
-  range:4320h:72h # For files under six months, keep one every 3 days.
+  range:3d:4h	  # For files under 3 days, keep one per 4 hours.
+  range:30d:1d    # For files under 30 days, keep one per day.
+  range:6mo:3d    # For files under six months, keep one every 3 days.
   # Beyond six months, files are deleted.
-`, *FieldSep, *FieldSep)
+
+Sample Calendar Config:
+  pathglob:/path/to/files/*.gz
+  timezone:Europe/London
+  range:24h:0       # For the first day, keep all.
+  daily:168h        # For files under a week, keep the last of each day.
+  weekly:1344h      # For files under eight weeks, keep the last of each week.
+  monthly:8784h     # For files under a year, keep the last of each month.
+  yearly:87840h     # For files under ten years, keep the last of each year.
+`, *FieldSep, *FieldSep, *FieldSep, *FieldSep, *FieldSep, ExitLimit)
+}
+
+// printDecisions writes one line per file with the action, path, range, and reason.
+func printDecisions(decisions bucket.Decisions) {
+	for _, d := range decisions {
+		action := "delete"
+		if d.Keep {
+			action = "keep"
+		}
+		fmt.Printf("%s\t%s\t%s (%s)\n", action, d.Object.ID(), rangeDesc(d.Range), d.Reason)
+	}
+}
+
+// printResult writes the files and bytes kept and deleted in each range followed by the totals.
+func printResult(result bucket.Result) {
+	for _, rr := range result.Ranges {
+		fmt.Printf("%s: %d kept (%d bytes), %d deleted (%d bytes)\n", rangeDesc(rr.Range), rr.Kept, rr.KeptBytes, rr.Deleted, rr.ReclaimedBytes)
+	}
+	fmt.Printf("Total: %d kept (%d bytes), %d deleted (%d bytes)\n", result.Kept, result.KeptBytes, result.Deleted, result.ReclaimedBytes)
+}
+
+func rangeDesc(r *agerotate.Range) string {
+	if r == nil {
+		return "Beyond all ranges"
+	}
+	return r.String()
+}
+
+// parseAsOf parses the -as-of flag. Dates are midnight in location, and values starting with + or - are durations added to now.
+func parseAsOf(s string, now time.Time, location *time.Location) (time.Time, error) {
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		offset, err := agerotate.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(offset), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if location == nil {
+		location = time.Local
+	}
+	t, err := time.ParseInLocation(config.AnchorLayout, s, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Expected RFC 3339, a date like %q, or an offset like +720h, got %q", config.AnchorLayout, s)
+	}
+	return t, nil
+}
+
+// setAsOf makes job measure ages and calendar periods from the time given by an -as-of flag, exiting if it's invalid.
+func setAsOf(value string, job *config.Job) {
+	asOf, err := parseAsOf(value, time.Now(), job.Cleaner.Location)
+	if err != nil {
+		errorExit("Invalid -as-of: %v\n", err)
+	}
+	job.Cleaner.Now = asOf
+	job.Files.Now = asOf
+}
+
+// runContext returns a context that's cancelled on SIGINT or SIGTERM, or once the timeout passes if it's non-zero.
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			fmt.Fprintf(os.Stderr, "Received %v, stopping after the current delete\n", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigs)
+	}()
+	return ctx, cancel
+}
+
+// loadJobs reads and parses the config at path in the format chosen by its extension, or every config in dir, exiting on failure.
+func loadJobs(path, dir, fieldSep string) []config.Job {
+	if dir != "" {
+		if path != "" {
+			errorExit("Use -config or -config-dir, not both\n")
+		}
+		jobs, err := config.ParseDir(dir, fieldSep)
+		if err != nil {
+			errorExit("Error parsing config directory %q: %v\n", dir, err)
+		}
+		return jobs
+	}
+	jobs, err := config.ParseFile(path, fieldSep)
+	if err != nil {
+		errorExit("Error parsing config %q: %v\n", path, err)
+	}
+	return jobs
+}
+
+// jobPrefix returns the prefix for messages about job, which is empty for a config without JOB lines.
+func jobPrefix(job config.Job) string {
+	if job.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf("Job %q: ", job.Name)
+}
+
+// printJobHeader starts the output for a named job so the output of several jobs can be told apart.
+func printJobHeader(job config.Job) {
+	if job.Name != "" {
+		fmt.Printf("Job %q\n", job.Name)
+	}
+}
+
+// jobFailed reports a problem with job and returns status.
+func jobFailed(job config.Job, status int, format string, a ...interface{}) int {
+	fmt.Fprint(os.Stderr, jobPrefix(job))
+	fmt.Fprintf(os.Stderr, format, a...)
+	return status
+}
+
+// runJob plans and, unless this is a dry run, applies the cleanup for one job. It returns the job's exit status rather than exiting so that a failure doesn't stop other jobs.
+func runJob(ctx context.Context, job config.Job) int {
+	cleaner := job.Cleaner
+	cleaner.ContinueOnError = *KeepGoing
+	cleaner.Workers = *Workers
+	decisions, err := cleaner.PlanContext(ctx, job.Ranges, job.Files)
+	if err != nil {
+		return jobFailed(job, -1, "Error planning cleanup: %v\n", err)
+	}
+
+	if *DryRun {
+		printJobHeader(job)
+		printDecisions(decisions)
+		printResult(decisions.Result())
+		if err := cleaner.CheckLimits(decisions); err != nil {
+			return jobFailed(job, ExitLimit, "A real run would delete nothing: %v\n", err)
+		}
+		return 0
+	}
+
+	for _, d := range decisions.Protected() {
+		fmt.Fprintf(os.Stderr, "%sNot deleting %s: %s\n", jobPrefix(job), d.Object.ID(), d.Reason)
+	}
+
+	result, err := cleaner.ApplyContext(ctx, decisions)
+	if _, ok := err.(*bucket.LimitError); ok {
+		return jobFailed(job, ExitLimit, "Aborting cleanup: %v\n", err)
+	}
+	if errs, ok := err.(bucket.DeleteErrors); ok {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%sError deleting %v\n", jobPrefix(job), e)
+		}
+		return jobFailed(job, -1, "Cleanup incomplete: %d deleted, %d failed\n", result.Deleted, result.Failed)
+	}
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return jobFailed(job, -1, "Cleanup stopped early: %v, %d deleted\n", err, result.Deleted)
+	}
+	if err != nil {
+		return jobFailed(job, -1, "Error doing cleanup: %v\n", err)
+	}
+	if *Summary {
+		printJobHeader(job)
+		printResult(result)
+	}
+	return 0
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "simulate":
+			simulateMain(os.Args[2:])
+			return
+		case "lint":
+			lintMain(os.Args[2:])
+			return
+		case "explain":
+			explainMain(os.Args[2:])
+			return
+		}
+	}
 	flag.Parse()
 
 	if *ShowFormat {
@@ -85,18 +410,27 @@ func main() {
 		return
 	}
 
-	cfg, err := os.Open(*ConfigPath)
-	if err != nil {
-		errorExit("Error opening config %q: %v\n", *ConfigPath, err)
+	jobs := loadJobs(*ConfigPath, *ConfigDir, *FieldSep)
+	if *AsOf != "" {
+		if !*DryRun {
+			errorExit("-as-of can only be used with -dry-run\n")
+		}
+		for i := range jobs {
+			setAsOf(*AsOf, &jobs[i])
+		}
 	}
 
-	files, ranges, err := config.Parse(cfg, *FieldSep)
-	if err != nil {
-		errorExit("Error parsing config %q: %v\n", *ConfigPath, err)
+	ctx, cancel := runContext(*Timeout)
+	defer cancel()
+	// A failure in any job takes precedence over a job stopped by MAXDELETE or MAXDELETEPERCENT.
+	status := 0
+	for _, job := range jobs {
+		if s := runJob(ctx, job); s != 0 && status != -1 {
+			status = s
+		}
 	}
-
-	err = bucket.Cleanup(ranges, files)
-	if err != nil {
-		errorExit("Error doing cleanup: %v\n", err)
+	if status != 0 {
+		cancel()
+		os.Exit(status)
 	}
 }
@@ -0,0 +1,51 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/AgentZombie/agerotate/fileobject/config"
+)
+
+// lintMain runs the lint subcommand, which prints warnings about settings in a config that are valid but probably mistakes.
+func lintMain(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to file rotation config.")
+	configDir := flags.String("config-dir", "", "Directory of configs to lint instead of -config.")
+	fieldSep := flags.String("fieldsep", ":", "Field separator for range lines.")
+	strict := flags.Bool("strict", false, fmt.Sprintf("Exit with status %d if there are any warnings.", ExitWarnings))
+	flags.Parse(args)
+
+	// Warnings about a config directory name the file themselves.
+	prefix := *configPath + ": "
+	var warnings []config.Warning
+	var err error
+	if *configDir != "" {
+		if *configPath != "" {
+			errorExit("Use -config or -config-dir, not both\n")
+		}
+		prefix = ""
+		warnings, err = config.LintDir(*configDir, *fieldSep)
+	} else {
+		warnings, err = config.LintFile(*configPath, *fieldSep)
+	}
+	if err != nil {
+		errorExit("Error parsing config %q: %v\n", *configPath+*configDir, err)
+	}
+	for _, w := range warnings {
+		fmt.Printf("%s%v\n", prefix, w)
+	}
+	if *strict && len(warnings) > 0 {
+		exitWith(ExitWarnings, "%d warnings\n", len(warnings))
+	}
+}
@@ -0,0 +1,124 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+	"github.com/AgentZombie/agerotate/fileobject/config"
+	"github.com/AgentZombie/agerotate/simulate"
+)
+
+// simulateMain runs the simulate subcommand, which replays a config against synthetic files and reports how many files each range holds and where gaps appear.
+func simulateMain(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to file rotation config.")
+	configDir := flags.String("config-dir", "", "Directory of configs to load instead of -config. Each .conf or .json file is a job named after the file unless it has JOB lines.")
+	fieldSep := flags.String("fieldsep", ":", "Field separator for range lines.")
+	jobName := flags.String("job", "", "Simulate only the job with this name. By default every job is simulated.")
+	every := durationFlag(flags, "every", time.Hour, "Time between new files.")
+	jitter := durationFlag(flags, "jitter", 0, "Move each new file up to this much earlier or later.")
+	span := durationFlag(flags, "span", 0, "Length of time to simulate, ending now, such as 1y. Defaults to twice the oldest range.")
+	step := durationFlag(flags, "step", 0, "Time between rotations. Defaults to -every.")
+	size := flags.Int64("size", 0, "Size of each file in bytes, for configs using MAXBYTES.")
+	seed := flags.Int64("seed", 1, "Seed for the random jitter.")
+	survivors := flags.Bool("survivors", false, "List the creation time of every file left at the end.")
+	flags.Parse(args)
+
+	found := false
+	for _, job := range loadJobs(*configPath, *configDir, *fieldSep) {
+		if *jobName != "" && job.Name != *jobName {
+			continue
+		}
+		found = true
+		printJobHeader(job)
+		simulateJob(job, simulate.Config{
+			Span:   *span,
+			Every:  *every,
+			Jitter: *jitter,
+			Step:   *step,
+			Size:   *size,
+			Seed:   *seed,
+		}, *survivors)
+	}
+	if !found {
+		errorExit("No job named %q in %q\n", *jobName, *configPath+*configDir)
+	}
+}
+
+// durationValue is a flag.Value for durations in the units config files accept, such as 1y or 30d.
+type durationValue time.Duration
+
+func (d *durationValue) String() string { return agerotate.FormatDuration(time.Duration(*d)) }
+
+func (d *durationValue) Set(s string) error {
+	v, err := agerotate.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = durationValue(v)
+	return nil
+}
+
+// durationFlag defines a flag on flags like flags.Duration that also accepts the units d, w, mo and y.
+func durationFlag(flags *flag.FlagSet, name string, value time.Duration, usage string) *time.Duration {
+	d := value
+	flags.Var((*durationValue)(&d), name, usage)
+	return &d
+}
+
+// simulateJob runs a simulation of job using the settings from the command line in c and prints the report.
+func simulateJob(job config.Job, c simulate.Config, survivors bool) {
+	if c.Span == 0 {
+		for _, r := range job.Ranges {
+			if 2*r.Age > c.Span {
+				c.Span = 2 * r.Age
+			}
+		}
+		if c.Span == 0 {
+			errorExit("%sConfig has only KEEPLAST ranges, -span is required\n", jobPrefix(job))
+		}
+	}
+	c.Ranges = job.Ranges
+	c.Cleaner = job.Cleaner
+	c.Start = time.Now().Add(-c.Span)
+
+	report, err := simulate.Run(c)
+	if err != nil {
+		errorExit("%sError simulating: %v\n", jobPrefix(job), err)
+	}
+
+	fmt.Printf("Simulated %d runs over %s: %d files created, %d deleted, %d runs blocked by MAXDELETE or MAXDELETEPERCENT\n", report.Runs, agerotate.FormatDuration(c.Span), report.Created, report.Deleted, report.Blocked)
+	if report.SteadyRuns == 0 {
+		fmt.Printf("The simulation ended before the oldest range filled, use a longer -span to see the steady state\n")
+	}
+	for _, rr := range report.Ranges {
+		if rr.Range == nil && rr.Max == 0 && rr.Final == 0 {
+			continue
+		}
+		steady := ""
+		if report.SteadyRuns > 0 {
+			steady = fmt.Sprintf("%d to %d files in steady state, ", rr.Min, rr.Max)
+		}
+		fmt.Printf("%s: %s%d at the end, longest gap %s\n", rangeDesc(rr.Range), steady, rr.Final, agerotate.FormatDuration(rr.MaxGap.Round(time.Second)))
+	}
+	for _, gap := range report.Gaps {
+		fmt.Printf("Gap: no files aged between %s and %s (%s)\n", agerotate.FormatDuration(gap.Younger.Round(time.Second)), agerotate.FormatDuration(gap.Older.Round(time.Second)), rangeDesc(gap.Range))
+	}
+	if survivors {
+		for _, t := range report.Survivors {
+			fmt.Println(t.Format(time.RFC3339))
+		}
+	}
+}
@@ -15,61 +15,513 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/AgentZombie/agerotate"
+	"github.com/AgentZombie/agerotate/bucket"
 	"github.com/AgentZombie/agerotate/fileobject"
 )
 
 const (
-	CommentChar = "#"
-	PathPrefix  = "pathglob"
-	RangePrefix = "range"
+	CommentChar            = "#"
+	JobPrefix              = "job"
+	IncludePrefix          = "include"
+	PathPrefix             = "pathglob"
+	RangePrefix            = "range"
+	MinKeepPrefix          = "minkeep"
+	MaxDeletePrefix        = "maxdelete"
+	MaxDeletePercentPrefix = "maxdeletepercent"
+	MaxBytesPrefix         = "maxbytes"
+	KeepLastPrefix         = "keeplast"
+	TimeZonePrefix         = "timezone"
+	AlignPrefix            = "align"
+	SelectPrefix           = "select"
+	NameTimePrefix         = "nametime"
+	NameRegexPrefix        = "nameregex"
+	NameUnmatchedPrefix    = "nameunmatched"
+	ExcludePrefix          = "exclude"
+	ExcludeRegexPrefix     = "excluderegex"
+	FollowSymlinksPrefix   = "followsymlinks"
+	MaxDepthPrefix         = "maxdepth"
+	RemoveEmptyDirsPrefix  = "removeemptydirs"
+
+	// AlignEpoch is the ALIGN value that aligns slots to the Unix epoch.
+	AlignEpoch = "epoch"
+	// AnchorLayout is the layout of ALIGN values other than AlignEpoch.
+	AnchorLayout = "2006-01-02"
+	// JSONExt is the extension of config files in JSON format.
+	JSONExt = ".json"
+	// ConfigExt is the extension of line format config files read from a config directory.
+	ConfigExt = ".conf"
 )
 
-// Parse reads and parses a config.
+// periodPrefixes maps the calendar range directives to their periods.
+var periodPrefixes = map[string]agerotate.Period{
+	"daily":   agerotate.Day,
+	"weekly":  agerotate.Week,
+	"monthly": agerotate.Month,
+	"yearly":  agerotate.Year,
+}
+
+// byteUnits maps the unit suffixes accepted by MAXBYTES, after removing any trailing B, to their multipliers. Units are powers of 1024.
+var byteUnits = map[string]int64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// unmatchedPolicies maps the NAMEUNMATCHED values to their policies.
+var unmatchedPolicies = map[string]fileobject.Unmatched{
+	fileobject.UnmatchedSkip.String():  fileobject.UnmatchedSkip,
+	fileobject.UnmatchedMTime.String(): fileobject.UnmatchedMTime,
+	fileobject.UnmatchedError.String(): fileobject.UnmatchedError,
+}
+
+// Job is everything needed to run one rotation: the files to rotate, the ranges to rotate them with, and the Cleaner settings from the config.
+type Job struct {
+	// Name is the name from the job's JOB line, or empty for a config without JOB lines.
+	Name    string
+	Files   fileobject.Glob
+	Ranges  []agerotate.Range
+	Cleaner bucket.Cleaner
+}
+
+// Parse reads and parses a config, returning only the files and ranges. A config using a directive the files and ranges can't carry, such as EXCLUDE, NAMETIME or MINKEEP, is an error rather than being silently ignored, since rotating without it could delete files the config protects; use ParseJob for those.
 func Parse(in io.Reader, fieldSep string) (fileobject.Files, []agerotate.Range, error) {
-	return newParser(in, fieldSep).parse()
+	job, err := ParseJob(in, fieldSep)
+	if err != nil {
+		return "", nil, err
+	}
+	if unsupported := unsupportedByParse(job); len(unsupported) > 0 {
+		return "", nil, fmt.Errorf("Config uses %s, which Parse can't return; use ParseJob", strings.Join(unsupported, ", "))
+	}
+	return fileobject.Files(job.Files.Pattern), job.Ranges, nil
+}
+
+// unsupportedByParse returns the directives used by job that are lost when it's reduced to a Files and ranges.
+func unsupportedByParse(job Job) []string {
+	unsupported := []string{}
+	add := func(used bool, prefix string) {
+		if used {
+			unsupported = append(unsupported, strings.ToUpper(prefix))
+		}
+	}
+	add(len(job.Files.Exclude) > 0, ExcludePrefix+"/"+ExcludeRegexPrefix)
+	add(job.Files.NameTime != nil, NameTimePrefix+"/"+NameRegexPrefix)
+	add(job.Files.FollowSymlinks, FollowSymlinksPrefix)
+	add(job.Files.MaxDepth > 0, MaxDepthPrefix)
+	add(job.Files.RemoveEmptyDirs, RemoveEmptyDirsPrefix)
+	add(job.Cleaner.MinKeep > 0, MinKeepPrefix)
+	add(job.Cleaner.MaxDelete > 0, MaxDeletePrefix)
+	add(job.Cleaner.MaxDeletePercent > 0, MaxDeletePercentPrefix)
+	add(job.Cleaner.MaxBytes > 0, MaxBytesPrefix)
+	add(job.Cleaner.Location != nil, TimeZonePrefix)
+	add(!job.Cleaner.Anchor.IsZero(), AlignPrefix)
+	return unsupported
+}
+
+// ParseJob reads and parses a config holding a single job into a Job.
+func ParseJob(in io.Reader, fieldSep string) (Job, error) {
+	jobs, err := ParseJobs(in, fieldSep)
+	if err != nil {
+		return Job{}, err
+	}
+	if len(jobs) != 1 {
+		return Job{}, fmt.Errorf("Config has %d jobs, expected one", len(jobs))
+	}
+	return jobs[0], nil
+}
+
+// ParseJobs reads and parses a config into one Job per JOB stanza. A config without JOB lines is a single job with no name.
+func ParseJobs(in io.Reader, fieldSep string) ([]Job, error) {
+	p := newParser(in, fieldSep)
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.jobs, nil
+}
+
+// ParseFile reads and parses the config at path into one Job per job it holds. Files ending in .json are read as JSON, see ParseJobsJSON. Any other file uses the line format with fieldSep separating values.
+func ParseFile(path, fieldSep string) ([]Job, error) {
+	p, err := parseFile(path, fieldSep)
+	if err != nil {
+		return nil, err
+	}
+	return p.jobs, nil
+}
+
+// ParseDir reads and parses every config in dir whose name ends in .conf or .json, in name order, into one Job per job they hold. Other files, including hidden files, are ignored. A config without JOB lines is a job named after its file without the extension. Job names must be unique across the directory.
+func ParseDir(dir, fieldSep string) ([]Job, error) {
+	p, err := parseDir(dir, fieldSep)
+	if err != nil {
+		return nil, err
+	}
+	return p.jobs, nil
+}
+
+// parseFile parses the config at path in the format chosen by its extension.
+func parseFile(path, fieldSep string) (*parser, error) {
+	p := newParser(nil, fieldSep)
+	if err := p.parsePath(path, ""); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseDir parses every config in dir with one parser, so job names are checked across files.
+func parseDir(dir, fieldSep string) (*parser, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	p := newParser(nil, fieldSep)
+	found := false
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if entry.IsDir() || strings.HasPrefix(name, ".") || (ext != ConfigExt && ext != JSONExt) {
+			continue
+		}
+		found = true
+		path := filepath.Join(dir, name)
+		p.jobState = jobState{ranges: []agerotate.Range{}}
+		p.defines = nil
+		p.defaultName = strings.TrimSuffix(name, filepath.Ext(name))
+		if err := p.parsePath(path, path); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("No %s or %s configs in %q", ConfigExt, JSONExt, dir)
+	}
+	return p, nil
+}
+
+// parsePath parses the config at path in the format chosen by its extension, naming it as file in errors.
+func (p *parser) parsePath(path, file string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == JSONExt {
+		p.file = file
+		err = p.parseJSON(in)
+		p.file = ""
+		if err != nil && file != "" {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		return err
+	}
+	if err := p.readFile(in, path, file); err != nil {
+		return err
+	}
+	p.file = file
+	err = p.finishJob()
+	p.file = ""
+	return err
 }
 
 type parser struct {
-	in       *bufio.Scanner
-	lineNo   int
+	in     *bufio.Scanner
+	lineNo int
+	// key is the path to the value being parsed in a structured config, such as jobs[0].minkeep. It's empty for the line format.
+	key      string
 	line     string
 	fieldSep string
-	path     string
-	ranges   []agerotate.Range
+	// jobs holds the jobs parsed so far and warnings the lint warnings about them.
+	jobs     []Job
+	warnings []Warning
+	// names holds the names of the JOB stanzas seen so far.
+	names map[string]bool
+	// file is the config being read when it must be named in errors: an included file or a file in a config directory. It's empty for the config the caller named.
+	file string
+	// dir is the directory of the config being read, which relative INCLUDE paths are resolved against. It's empty when reading from a Reader.
+	dir string
+	// including holds the absolute paths of the configs being read, outermost first, to detect INCLUDE cycles.
+	including []string
+	// defaultName names a job without a JOB line when reading a config directory.
+	defaultName string
+	// defines holds the variables from DEFINE lines. They apply to the rest of the config, including every job after them.
+	defines map[string]string
+	jobState
+}
+
+// position is where a value came from: a line number in the line format or a key path in a structured config, and the file if it must be named.
+type position struct {
+	line int
+	key  string
+	file string
+}
+
+func (pos position) String() string {
+	if pos.key != "" {
+		return pos.key
+	}
+	if pos.file != "" {
+		return fmt.Sprintf("Line %d of %s", pos.line, pos.file)
+	}
+	return fmt.Sprintf("Line %d", pos.line)
+}
+
+// pos returns the position of the value being parsed.
+func (p *parser) pos() position {
+	return position{line: p.lineNo, key: p.key, file: p.file}
+}
+
+// jobState is the part of the parser's state that belongs to the job being parsed. It's reset at each JOB line.
+type jobState struct {
+	name string
+	// directives counts the lines parsed for the job.
+	directives int
+	path       string
+	pathPos    position
+	ranges     []agerotate.Range
+	// rangePos holds where each of ranges came from.
+	rangePos []position
+	keepLast int
+	align    bool
+	selector agerotate.Selector
+	// selectPos is where selector came from.
+	selectPos position
+	// nameExpr is the NAMETIME layout or NAMEREGEX expression, nameRegex says which, and namePos is where it came from.
+	nameExpr  string
+	nameRegex bool
+	namePos   position
+	unmatched *fileobject.Unmatched
+	excludes  []*fileobject.Exclude
+	anchor    time.Time
+	cleaner   bucket.Cleaner
+	// unmatchedPos is where unmatched came from.
+	unmatchedPos position
+	// followSymlinks, maxDepth and removeEmptyDirs are copied to the Glob's fields of the same name.
+	followSymlinks  bool
+	maxDepth        int
+	removeEmptyDirs bool
+	// seen maps the directives that may only appear once to the INCLUDE depth they were set at.
+	seen map[string]int
 }
 
 func newParser(in io.Reader, fieldSep string) *parser {
 	return &parser{
 		in:       bufio.NewScanner(in),
 		fieldSep: fieldSep,
-		ranges:   []agerotate.Range{},
+		jobs:     []Job{},
+		warnings: []Warning{},
+		names:    map[string]bool{},
+		jobState: jobState{ranges: []agerotate.Range{}},
+	}
+}
+
+// parse manages the parser context, finishing each job as the next JOB line or the end of the config is reached.
+func (p *parser) parse() error {
+	if err := p.readLines(); err != nil {
+		return err
 	}
+	return p.finishJob()
 }
 
-// parse manages the parser context and performs some sanity checking on the resulting objects before returning them.
-func (p *parser) parse() (fileobject.Files, []agerotate.Range, error) {
+// readLines parses each line of the current input.
+func (p *parser) readLines() error {
 	for p.in.Scan() {
 		p.line = p.in.Text()
 		p.lineNo += 1
 		err := p.parseLine()
 		if err != nil {
-			return "", nil, err
+			return err
 		}
 	}
-	if err := p.in.Err(); err != nil {
-		return "", nil, err
+	return p.in.Err()
+}
+
+// readFile parses the lines of the config at path, read from in, as if they appeared in place of the current line. The config is named as file in errors.
+func (p *parser) readFile(in io.Reader, path, file string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	savedIn, savedLineNo, savedFile, savedDir := p.in, p.lineNo, p.file, p.dir
+	p.in, p.lineNo, p.file, p.dir = bufio.NewScanner(in), 0, file, filepath.Dir(path)
+	p.including = append(p.including, abs)
+	err = p.readLines()
+	p.in, p.lineNo, p.file, p.dir = savedIn, savedLineNo, savedFile, savedDir
+	p.including = p.including[:len(p.including)-1]
+	return err
+}
+
+// include handles an INCLUDE line by reading every file matching its glob, in name order, in place of the line. Relative paths are resolved against the directory of the config holding the line. A glob matching nothing is ignored, but a path without wildcards must exist.
+func (p *parser) include(values []string) error {
+	if len(values) != 1 || values[0] == "" {
+		return fmt.Errorf("%v: Include lines must have one value", p.pos())
+	}
+	pattern := values[0]
+	if !filepath.IsAbs(pattern) && p.dir != "" {
+		pattern = filepath.Join(p.dir, pattern)
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("%v: Invalid include pattern %q: %v", p.pos(), values[0], err)
+	}
+	if len(paths) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return fmt.Errorf("%v: Included config %q does not exist", p.pos(), pattern)
+	}
+	for _, path := range paths {
+		if err := p.includeFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includeFile reads a single included config after checking that it isn't already being read.
+func (p *parser) includeFile(path string) error {
+	if strings.ToLower(filepath.Ext(path)) == JSONExt {
+		return fmt.Errorf("%v: Can't include JSON config %q", p.pos(), path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%v: %v", p.pos(), err)
+	}
+	for i, f := range p.including {
+		if f == abs {
+			return fmt.Errorf("%v: Include cycle: %s -> %s", p.pos(), strings.Join(p.including[i:], " -> "), abs)
+		}
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%v: %v", p.pos(), err)
 	}
+	defer in.Close()
+	return p.readFile(in, path, path)
+}
+
+// startJob handles a JOB line by finishing the job before it and starting a new one.
+func (p *parser) startJob(values []string) error {
+	if len(values) != 1 || values[0] == "" {
+		return fmt.Errorf("%v: Job lines must have one value", p.pos())
+	}
+	if p.name == "" && p.directives > 0 {
+		return fmt.Errorf("%v: Every directive must be inside a job once JOB is used", p.pos())
+	}
+	if p.names[values[0]] {
+		return fmt.Errorf("%v: Duplicate job %q", p.pos(), values[0])
+	}
+	if p.name != "" {
+		if err := p.finishJob(); err != nil {
+			return err
+		}
+	}
+	p.names[values[0]] = true
+	p.jobState = jobState{name: values[0], ranges: []agerotate.Range{}}
+	return nil
+}
+
+// finishJob performs some sanity checking on the job being parsed and adds it to the parsed jobs. Errors name the job if it has one.
+func (p *parser) finishJob() error {
+	if p.name == "" && p.defaultName != "" {
+		if p.names[p.defaultName] {
+			return fmt.Errorf("%s: Duplicate job %q", p.file, p.defaultName)
+		}
+		p.names[p.defaultName] = true
+		p.name = p.defaultName
+	}
+	job, err := p.job()
+	if err != nil {
+		if p.name != "" {
+			return fmt.Errorf("Job %q: %v", p.name, err)
+		}
+		if p.key != "" && !strings.HasPrefix(err.Error(), p.key) {
+			return fmt.Errorf("%s: %v", p.key, err)
+		}
+		return err
+	}
+	p.jobs = append(p.jobs, job)
+	p.warnings = append(p.warnings, p.lint(job)...)
+	return nil
+}
+
+// job builds the Job for the stanza being parsed.
+func (p *parser) job() (Job, error) {
 	if p.path == "" {
-		return "", nil, fmt.Errorf("No file rotation path specified")
+		return Job{}, fmt.Errorf("No file rotation path specified")
 	}
-	if len(p.ranges) == 0 {
-		return "", nil, fmt.Errorf("No ranges specified")
+	ranges := p.ranges
+	if p.keepLast > 0 {
+		ranges = append([]agerotate.Range{{Count: p.keepLast}}, ranges...)
+	}
+	if len(ranges) == 0 {
+		return Job{}, fmt.Errorf("No ranges specified")
+	}
+	if p.align && p.selector != nil && !bucket.IsSlotSelector(p.selector) {
+		return Job{}, fmt.Errorf("%v: Selector %q would replace ALIGN; only midpoint and largest keep one file per aligned slot", p.selectPos, p.selector)
+	}
+	for i := range ranges {
+		if ranges[i].Period == agerotate.NoPeriod && ranges[i].Count == 0 {
+			ranges[i].Aligned = p.align
+			ranges[i].Selector = p.selector
+		}
 	}
-	return fileobject.Files(p.path), p.ranges, nil
+	if p.align {
+		if !p.anchor.IsZero() {
+			location := p.cleaner.Location
+			if location == nil {
+				location = time.Local
+			}
+			year, month, day := p.anchor.Date()
+			p.cleaner.Anchor = time.Date(year, month, day, 0, 0, 0, 0, location)
+		}
+	}
+	files, err := p.files()
+	if err != nil {
+		return Job{}, err
+	}
+	return Job{
+		Name:    p.name,
+		Files:   files,
+		Ranges:  ranges,
+		Cleaner: p.cleaner,
+	}, nil
+}
+
+// files builds the Glob for the job once TIMEZONE is known, since it's used to interpret timestamps in file names.
+func (p *parser) files() (fileobject.Glob, error) {
+	files := fileobject.Glob{
+		Pattern:         p.path,
+		Exclude:         p.excludes,
+		FollowSymlinks:  p.followSymlinks,
+		MaxDepth:        p.maxDepth,
+		RemoveEmptyDirs: p.removeEmptyDirs,
+	}
+	if (p.followSymlinks || p.maxDepth > 0) && !fileobject.IsRecursive(p.path) {
+		return files, fmt.Errorf("%v: %s and %s require a %s element in the path", p.pathPos, strings.ToUpper(FollowSymlinksPrefix), strings.ToUpper(MaxDepthPrefix), fileobject.Recursive)
+	}
+	if p.nameExpr == "" {
+		if p.unmatched != nil {
+			return files, fmt.Errorf("%v: Nameunmatched requires %s or %s", p.unmatchedPos, strings.ToUpper(NameTimePrefix), strings.ToUpper(NameRegexPrefix))
+		}
+		return files, nil
+	}
+	var err error
+	if p.nameRegex {
+		files.NameTime, err = fileobject.NewRegexpNameTime(p.nameExpr, p.cleaner.Location)
+	} else {
+		files.NameTime, err = fileobject.NewStrftimeNameTime(p.nameExpr, p.cleaner.Location)
+	}
+	if err != nil {
+		return files, fmt.Errorf("%v: %v", p.namePos, err)
+	}
+	if p.unmatched != nil {
+		files.Unmatched = *p.unmatched
+	}
+	return files, nil
 }
 
 // parseLine parses the line that's just been read in by parse(), invoking handling functions specified to each line type.
@@ -80,56 +532,399 @@ func (p *parser) parseLine() error {
 	}
 	fields := strings.Split(p.line, p.fieldSep)
 	if len(fields) < 2 {
-		return fmt.Errorf("Line %d: Missing values", p.lineNo)
+		return fmt.Errorf("%v: Missing values", p.pos())
 	}
 	prefix := strings.ToLower(fields[0])
+	values, err := p.expandAll(fields[1:])
+	if err != nil {
+		return err
+	}
+	switch prefix {
+	case JobPrefix:
+		return p.startJob(values)
+	case IncludePrefix:
+		return p.include(values)
+	case DefinePrefix:
+		return p.define(values)
+	}
+	p.directives++
+	return p.directive(prefix, values)
+}
+
+// directive applies a single directive other than JOB to the job being parsed.
+func (p *parser) directive(prefix string, values []string) error {
 	switch prefix {
 	case PathPrefix:
-		return p.setPath(fields[1:])
+		return p.setPath(values)
 	case RangePrefix:
-		return p.addRange(fields[1:])
-	default:
-		return fmt.Errorf("Line %d: Invalid prefix %q", p.lineNo, prefix)
+		return p.addRange(values)
+	case MinKeepPrefix:
+		return p.setMinKeep(values)
+	case MaxDeletePrefix:
+		return p.setMaxDelete(values)
+	case MaxDeletePercentPrefix:
+		return p.setMaxDeletePercent(values)
+	case MaxBytesPrefix:
+		return p.setMaxBytes(values)
+	case KeepLastPrefix:
+		return p.setKeepLast(values)
+	case TimeZonePrefix:
+		return p.setTimeZone(values)
+	case AlignPrefix:
+		return p.setAlign(values)
+	case SelectPrefix:
+		return p.setSelect(values)
+	case NameTimePrefix, NameRegexPrefix:
+		return p.setNameTime(prefix, values)
+	case NameUnmatchedPrefix:
+		return p.setNameUnmatched(values)
+	case ExcludePrefix, ExcludeRegexPrefix:
+		return p.addExclude(prefix, values)
+	case FollowSymlinksPrefix:
+		return p.setFollowSymlinks(values)
+	case MaxDepthPrefix:
+		return p.setMaxDepth(values)
+	case RemoveEmptyDirsPrefix:
+		return p.setRemoveEmptyDirs(values)
 	}
-	return nil
+	if period, ok := periodPrefixes[prefix]; ok {
+		return p.addPeriodRange(prefix, period, values)
+	}
+	return fmt.Errorf("%v: Invalid prefix %q", p.pos(), prefix)
 }
 
 func (p *parser) setPath(values []string) error {
 	if p.path != "" {
-		return fmt.Errorf("Line %d: Duplicate path specification", p.lineNo)
+		return fmt.Errorf("%v: Duplicate path specification", p.pos())
 	}
 	if len(values) != 1 {
-		return fmt.Errorf("Line %d: Using multiple path values is invalid", p.lineNo)
+		return fmt.Errorf("%v: Using multiple path values is invalid", p.pos())
 	}
 	if values[0] == "" {
-		return fmt.Errorf("Line %d: Must specify path", p.lineNo)
+		return fmt.Errorf("%v: Must specify path", p.pos())
 	}
 	p.path = values[0]
+	p.pathPos = p.pos()
 	return nil
 }
 
 func (p *parser) addRange(values []string) error {
 	if len(values) != 2 {
-		return fmt.Errorf("Line %d: Range lines must have two values", p.lineNo)
+		return fmt.Errorf("%v: Range lines must have two values", p.pos())
 	}
-	age, err := time.ParseDuration(values[0])
+	age, err := agerotate.ParseDuration(values[0])
 	if err != nil {
-		return fmt.Errorf("Line %d: Invalid age: %v", p.lineNo, err.Error())
+		return fmt.Errorf("%v: Invalid age: %v", p.pos(), err.Error())
 	}
-	interval, err := time.ParseDuration(values[1])
+	interval, err := agerotate.ParseDuration(values[1])
 	if err != nil {
-		return fmt.Errorf("Line %d: Invalid interval: %v", p.lineNo, err.Error())
-	}
-	if age < 0 {
-		return fmt.Errorf("Line %d: Age values must be positive, got %v", p.lineNo, age)
+		return fmt.Errorf("%v: Invalid interval: %v", p.pos(), err.Error())
 	}
 	if interval < 0 {
-		return fmt.Errorf("Line %d: Interval values must be positive, got %v", p.lineNo, interval)
+		return fmt.Errorf("%v: Interval values must be positive, got %v", p.pos(), interval)
+	}
+	return p.appendRange(agerotate.Range{Age: age, Interval: interval})
+}
+
+func (p *parser) addPeriodRange(prefix string, period agerotate.Period, values []string) error {
+	if len(values) != 1 {
+		return fmt.Errorf("%v: %s lines must have one value", p.pos(), strings.ToUpper(prefix[:1])+prefix[1:])
+	}
+	age, err := agerotate.ParseDuration(values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Invalid age: %v", p.pos(), err.Error())
+	}
+	return p.appendRange(agerotate.Range{Age: age, Period: period})
+}
+
+// appendRange checks the age of a range from a RANGE or calendar line against the ranges before it and adds it.
+func (p *parser) appendRange(r agerotate.Range) error {
+	if r.Age < 0 {
+		return fmt.Errorf("%v: Age values must be positive, got %v", p.pos(), r.Age)
+	}
+	if len(p.ranges) > 0 && p.ranges[len(p.ranges)-1].Age >= r.Age {
+		return fmt.Errorf("%v: Age value must be larger than previous age value", p.pos())
+	}
+	p.ranges = append(p.ranges, r)
+	p.rangePos = append(p.rangePos, p.pos())
+	return nil
+}
+
+func (p *parser) setTimeZone(values []string) error {
+	if err := p.once(TimeZonePrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Timezone lines must have one value", p.pos())
+	}
+	location, err := time.LoadLocation(values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Invalid timezone: %v", p.pos(), err.Error())
+	}
+	p.cleaner.Location = location
+	return nil
+}
+
+func (p *parser) setKeepLast(values []string) error {
+	if err := p.once(KeepLastPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Keeplast lines must have one value", p.pos())
+	}
+	keepLast, err := strconv.Atoi(values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Invalid keeplast: %v", p.pos(), err.Error())
+	}
+	if keepLast < 1 {
+		return fmt.Errorf("%v: Keeplast must be at least 1, got %d", p.pos(), keepLast)
+	}
+	p.keepLast = keepLast
+	return nil
+}
+
+func (p *parser) setMinKeep(values []string) error {
+	if err := p.once(MinKeepPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Minkeep lines must have one value", p.pos())
+	}
+	minKeep, err := strconv.Atoi(values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Invalid minkeep: %v", p.pos(), err.Error())
+	}
+	if minKeep < 0 {
+		return fmt.Errorf("%v: Minkeep must be positive, got %d", p.pos(), minKeep)
+	}
+	p.cleaner.MinKeep = minKeep
+	return nil
+}
+
+func (p *parser) setMaxDelete(values []string) error {
+	if err := p.once(MaxDeletePrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Maxdelete lines must have one value", p.pos())
+	}
+	maxDelete, err := strconv.Atoi(values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Invalid maxdelete: %v", p.pos(), err.Error())
+	}
+	if maxDelete < 1 {
+		return fmt.Errorf("%v: Maxdelete must be at least 1, got %d", p.pos(), maxDelete)
+	}
+	p.cleaner.MaxDelete = maxDelete
+	return nil
+}
+
+func (p *parser) setMaxDeletePercent(values []string) error {
+	if err := p.once(MaxDeletePercentPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Maxdeletepercent lines must have one value", p.pos())
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(values[0], "%"), 64)
+	if err != nil {
+		return fmt.Errorf("%v: Invalid maxdeletepercent: %v", p.pos(), err.Error())
+	}
+	if percent <= 0 || percent > 100 {
+		return fmt.Errorf("%v: Maxdeletepercent must be above 0 and at most 100, got %g", p.pos(), percent)
+	}
+	p.cleaner.MaxDeletePercent = percent
+	return nil
+}
+
+func (p *parser) setMaxBytes(values []string) error {
+	if err := p.once(MaxBytesPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Maxbytes lines must have one value", p.pos())
+	}
+	maxBytes, err := parseBytes(values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Invalid maxbytes: %v", p.pos(), err.Error())
+	}
+	if maxBytes < 1 {
+		return fmt.Errorf("%v: Maxbytes must be at least 1, got %d", p.pos(), maxBytes)
+	}
+	p.cleaner.MaxBytes = maxBytes
+	return nil
+}
+
+// parseBytes parses a byte count with an optional unit suffix from byteUnits, such as 500, 20G or 1.5TB.
+func parseBytes(s string) (int64, error) {
+	lower := strings.TrimSuffix(strings.ToLower(s), "b")
+	split := strings.IndexFunc(lower, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split == -1 {
+		split = len(lower)
+	}
+	unit, ok := byteUnits[lower[split:]]
+	if !ok {
+		return 0, fmt.Errorf("Unknown unit in %q", s)
+	}
+	n, err := strconv.ParseFloat(lower[:split], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid number in %q", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+func (p *parser) setAlign(values []string) error {
+	if err := p.once(AlignPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Align lines must have one value", p.pos())
+	}
+	p.align = true
+	p.anchor = time.Time{}
+	if strings.ToLower(values[0]) == AlignEpoch {
+		return nil
+	}
+	anchor, err := time.Parse(AnchorLayout, values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Align value must be %q or a date like %q, got %q", p.pos(), AlignEpoch, AnchorLayout, values[0])
+	}
+	p.anchor = anchor
+	return nil
+}
+
+func (p *parser) setSelect(values []string) error {
+	if err := p.once(SelectPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Select lines must have one value", p.pos())
+	}
+	selector, ok := bucket.Selectors[strings.ToLower(values[0])]
+	if !ok {
+		return fmt.Errorf("%v: Unknown selector %q", p.pos(), values[0])
+	}
+	p.selector = selector
+	p.selectPos = p.pos()
+	return nil
+}
+
+// setNameTime records a NAMETIME layout or NAMEREGEX expression. Either may contain the field separator, so the values are joined back together.
+func (p *parser) setNameTime(prefix string, values []string) error {
+	if p.nameExpr != "" {
+		return fmt.Errorf("%v: Duplicate name timestamp specification", p.pos())
+	}
+	expr := strings.Join(values, p.fieldSep)
+	if expr == "" {
+		return fmt.Errorf("%v: Must specify %s", p.pos(), prefix)
+	}
+	p.nameExpr = expr
+	p.nameRegex = prefix == NameRegexPrefix
+	p.namePos = p.pos()
+	return nil
+}
+
+func (p *parser) setNameUnmatched(values []string) error {
+	if err := p.once(NameUnmatchedPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Nameunmatched lines must have one value", p.pos())
+	}
+	unmatched, ok := unmatchedPolicies[strings.ToLower(values[0])]
+	if !ok {
+		return fmt.Errorf("%v: Nameunmatched must be skip, mtime or error, got %q", p.pos(), values[0])
+	}
+	p.unmatched = &unmatched
+	p.unmatchedPos = p.pos()
+	return nil
+}
+
+// addExclude records an EXCLUDE glob or EXCLUDEREGEX expression. Either may contain the field separator, so the values are joined back together.
+func (p *parser) addExclude(prefix string, values []string) error {
+	expr := strings.Join(values, p.fieldSep)
+	if expr == "" {
+		return fmt.Errorf("%v: Must specify %s", p.pos(), prefix)
+	}
+	var exclude *fileobject.Exclude
+	var err error
+	if prefix == ExcludeRegexPrefix {
+		exclude, err = fileobject.NewRegexpExclude(expr)
+	} else {
+		exclude, err = fileobject.NewGlobExclude(expr)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: Invalid %s %q: %v", p.pos(), prefix, expr, err)
+	}
+	p.excludes = append(p.excludes, exclude)
+	return nil
+}
+
+func (p *parser) setFollowSymlinks(values []string) error {
+	if err := p.once(FollowSymlinksPrefix); err != nil {
+		return err
+	}
+	follow, err := p.parseBool("Followsymlinks", values)
+	if err != nil {
+		return err
+	}
+	p.followSymlinks = follow
+	return nil
+}
+
+func (p *parser) setMaxDepth(values []string) error {
+	if err := p.once(MaxDepthPrefix); err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%v: Maxdepth lines must have one value", p.pos())
+	}
+	maxDepth, err := strconv.Atoi(values[0])
+	if err != nil {
+		return fmt.Errorf("%v: Invalid maxdepth: %v", p.pos(), err.Error())
+	}
+	if maxDepth < 1 {
+		return fmt.Errorf("%v: Maxdepth must be at least 1, got %d", p.pos(), maxDepth)
+	}
+	p.maxDepth = maxDepth
+	return nil
+}
+
+func (p *parser) setRemoveEmptyDirs(values []string) error {
+	if err := p.once(RemoveEmptyDirsPrefix); err != nil {
+		return err
+	}
+	remove, err := p.parseBool("Removeemptydirs", values)
+	if err != nil {
+		return err
+	}
+	p.removeEmptyDirs = remove
+	return nil
+}
+
+// parseBool parses the single true or false value of the directive called name, accepting anything strconv.ParseBool does.
+func (p *parser) parseBool(name string, values []string) (bool, error) {
+	if len(values) != 1 {
+		return false, fmt.Errorf("%v: %s lines must have one value", p.pos(), name)
+	}
+	b, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, fmt.Errorf("%v: %s must be true or false, got %q", p.pos(), name, values[0])
+	}
+	return b, nil
+}
+
+// once returns an error if a directive that may only appear once has already been seen, unless it was seen in an included config and is being overridden by a config that includes it.
+func (p *parser) once(prefix string) error {
+	if p.seen == nil {
+		p.seen = map[string]int{}
 	}
-	if len(p.ranges) > 0 && p.ranges[len(p.ranges)-1].Age >= age {
-		return fmt.Errorf("Line %d: Age value must be larger than previous age value", p.lineNo)
+	if depth, ok := p.seen[prefix]; ok && depth <= len(p.including) {
+		return fmt.Errorf("%v: Duplicate %s specification", p.pos(), prefix)
 	}
-	p.ranges = append(p.ranges, agerotate.Range{Age: age, Interval: interval})
+	p.seen[prefix] = len(p.including)
 	return nil
 }
 
@@ -11,11 +11,16 @@ THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLI
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/AgentZombie/agerotate"
+	"github.com/AgentZombie/agerotate/bucket"
+	"github.com/AgentZombie/agerotate/fileobject"
 )
 
 const (
@@ -139,8 +144,10 @@ func TestRangeLarger(t *testing.T) {
 	p := parser{
 		line:     line,
 		fieldSep: ":",
-		ranges: []agerotate.Range{
-			agerotate.Range{Age: 60 * time.Second},
+		jobState: jobState{
+			ranges: []agerotate.Range{
+				agerotate.Range{Age: 60 * time.Second},
+			},
 		},
 	}
 	expected := "Line 0: Age value must be larger than previous age value"
@@ -187,3 +194,1077 @@ func TestFull(t *testing.T) {
 		}
 	}
 }
+
+func TestParseUnsupported(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		input       string
+		expectedErr string
+	}{
+		{
+			id:    "Files and ranges only",
+			input: "pathglob:/x/**/*.gz\nrange:24h:0\nweekly:720h\n",
+		},
+		{
+			id:          "Excludes",
+			input:       "pathglob:/x/*\nrange:24h:0\nexclude:keep\nexcluderegex:\\.partial$\n",
+			expectedErr: "Config uses EXCLUDE/EXCLUDEREGEX, which Parse can't return; use ParseJob",
+		},
+		{
+			id:          "Cleaner settings",
+			input:       "pathglob:/x/*\nrange:24h:0\nminkeep:3\nmaxdelete:10\nmaxbytes:1G\n",
+			expectedErr: "Config uses MINKEEP, MAXDELETE, MAXBYTES, which Parse can't return; use ParseJob",
+		},
+		{
+			id:          "Name timestamps",
+			input:       "pathglob:/x/*\nrange:24h:0\nnametime:%Y%m%d\n",
+			expectedErr: "Config uses NAMETIME/NAMEREGEX, which Parse can't return; use ParseJob",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		_, _, err := Parse(strings.NewReader(tc.input), ":")
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("Got unexpected error %q", err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.expectedErr {
+			t.Fatalf("Expected error %q, got %v", tc.expectedErr, err)
+		}
+	}
+}
+
+func TestMinKeep(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		lines       []string
+		expectedErr string
+		expected    int
+	}{
+		{
+			id:       "Valid",
+			lines:    []string{"MINKEEP:5"},
+			expected: 5,
+		},
+		{
+			id:          "Missing value",
+			lines:       []string{"minkeep:"},
+			expectedErr: "Line 0: Invalid minkeep: strconv.Atoi: parsing \"\": invalid syntax",
+		},
+		{
+			id:          "Too many values",
+			lines:       []string{"minkeep:1:2"},
+			expectedErr: "Line 0: Minkeep lines must have one value",
+		},
+		{
+			id:          "Negative",
+			lines:       []string{"minkeep:-1"},
+			expectedErr: "Line 0: Minkeep must be positive, got -1",
+		},
+		{
+			id:          "Duplicate",
+			lines:       []string{"minkeep:1", "minkeep:2"},
+			expectedErr: "Line 0: Duplicate minkeep specification",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		p := parser{fieldSep: ":"}
+		var err error
+		for _, line := range tc.lines {
+			p.line = line
+			if err = p.parseLine(); err != nil {
+				break
+			}
+		}
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("Expected no error, got %q", err)
+			}
+			if p.cleaner.MinKeep != tc.expected {
+				t.Fatalf("Expected minkeep %d, got %d", tc.expected, p.cleaner.MinKeep)
+			}
+		} else {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+		}
+	}
+}
+
+func TestParseJob(t *testing.T) {
+	in := strings.NewReader(fullInput + "MinKeep:3\n")
+	job, err := ParseJob(in, ":")
+	if err != nil {
+		t.Fatalf("Got unexpected error %q", err)
+	}
+	if job.Files.Pattern != "/path/to/whatever/*" {
+		t.Fatalf("Expected files path %q, got %q", "/path/to/whatever/*", job.Files.Pattern)
+	}
+	if len(job.Ranges) != 3 {
+		t.Fatalf("Expected 3 ranges, got %d", len(job.Ranges))
+	}
+	if job.Cleaner.MinKeep != 3 {
+		t.Fatalf("Expected minkeep 3, got %d", job.Cleaner.MinKeep)
+	}
+}
+
+func TestMaxDelete(t *testing.T) {
+	for _, tc := range []struct {
+		id              string
+		lines           []string
+		expectedErr     string
+		expectedCount   int
+		expectedPercent float64
+	}{
+		{
+			id:              "Both limits",
+			lines:           []string{"MAXDELETE:20", "MaxDeletePercent:12.5%"},
+			expectedCount:   20,
+			expectedPercent: 12.5,
+		},
+		{
+			id:              "Percent without sign",
+			lines:           []string{"maxdeletepercent:50"},
+			expectedPercent: 50,
+		},
+		{
+			id:          "Zero count",
+			lines:       []string{"maxdelete:0"},
+			expectedErr: "Line 0: Maxdelete must be at least 1, got 0",
+		},
+		{
+			id:          "Percent too large",
+			lines:       []string{"maxdeletepercent:101"},
+			expectedErr: "Line 0: Maxdeletepercent must be above 0 and at most 100, got 101",
+		},
+		{
+			id:          "Invalid percent",
+			lines:       []string{"maxdeletepercent:lots"},
+			expectedErr: "Line 0: Invalid maxdeletepercent: strconv.ParseFloat: parsing \"lots\": invalid syntax",
+		},
+		{
+			id:          "Duplicate count",
+			lines:       []string{"maxdelete:1", "maxdelete:2"},
+			expectedErr: "Line 0: Duplicate maxdelete specification",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		p := parser{fieldSep: ":"}
+		var err error
+		for _, line := range tc.lines {
+			p.line = line
+			if err = p.parseLine(); err != nil {
+				break
+			}
+		}
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("Expected no error, got %q", err)
+			}
+			if p.cleaner.MaxDelete != tc.expectedCount || p.cleaner.MaxDeletePercent != tc.expectedPercent {
+				t.Fatalf("Expected limits %d and %g, got %d and %g", tc.expectedCount, tc.expectedPercent, p.cleaner.MaxDelete, p.cleaner.MaxDeletePercent)
+			}
+		} else {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+		}
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		line        string
+		expectedErr string
+		expected    int64
+	}{
+		{
+			id:       "Plain bytes",
+			line:     "MAXBYTES:1000",
+			expected: 1000,
+		},
+		{
+			id:       "Bytes with B",
+			line:     "maxbytes:1000B",
+			expected: 1000,
+		},
+		{
+			id:       "Gigabytes",
+			line:     "maxbytes:20G",
+			expected: 20 << 30,
+		},
+		{
+			id:       "Fractional terabytes",
+			line:     "maxbytes:1.5tb",
+			expected: 3 << 39,
+		},
+		{
+			id:          "Unknown unit",
+			line:        "maxbytes:5q",
+			expectedErr: "Line 0: Invalid maxbytes: Unknown unit in \"5q\"",
+		},
+		{
+			id:          "Missing number",
+			line:        "maxbytes:GB",
+			expectedErr: "Line 0: Invalid maxbytes: Invalid number in \"GB\"",
+		},
+		{
+			id:          "Zero",
+			line:        "maxbytes:0",
+			expectedErr: "Line 0: Maxbytes must be at least 1, got 0",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		p := parser{line: tc.line, fieldSep: ":"}
+		err := p.parseLine()
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("Expected no error, got %q", err)
+			}
+			if p.cleaner.MaxBytes != tc.expected {
+				t.Fatalf("Expected maxbytes %d, got %d", tc.expected, p.cleaner.MaxBytes)
+			}
+		} else {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+		}
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	for _, tc := range []struct {
+		id             string
+		input          string
+		expectedErr    string
+		expectedRanges []agerotate.Range
+	}{
+		{
+			id:    "Keep last with ranges",
+			input: "pathglob:/x/*\nrange:1h:0\nkeeplast:10\nrange:24h:1h\n",
+			expectedRanges: []agerotate.Range{
+				{Count: 10},
+				{Age: time.Hour},
+				{Age: 24 * time.Hour, Interval: time.Hour},
+			},
+		},
+		{
+			id:             "Keep last alone",
+			input:          "pathglob:/x/*\nKEEPLAST:3\n",
+			expectedRanges: []agerotate.Range{{Count: 3}},
+		},
+		{
+			id:          "Zero",
+			input:       "pathglob:/x/*\nkeeplast:0\n",
+			expectedErr: "Line 2: Keeplast must be at least 1, got 0",
+		},
+		{
+			id:          "Duplicate",
+			input:       "pathglob:/x/*\nkeeplast:1\nkeeplast:2\n",
+			expectedErr: "Line 3: Duplicate keeplast specification",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(job.Ranges) != len(tc.expectedRanges) {
+			t.Fatalf("Expected ranges %v, got %v", tc.expectedRanges, job.Ranges)
+		}
+		for i := range tc.expectedRanges {
+			if tc.expectedRanges[i] != job.Ranges[i] {
+				t.Fatalf("Expected range %d to be %q, got %q", i, tc.expectedRanges[i], job.Ranges[i])
+			}
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	for _, tc := range []struct {
+		id               string
+		input            string
+		expectedErr      string
+		expectedRanges   []agerotate.Range
+		expectedLocation string
+	}{
+		{
+			id:    "Grandfather-father-son",
+			input: "pathglob:/x/*\nrange:24h:0\ndaily:168h\nWeekly:1344h\nMONTHLY:8760h\nyearly:87600h\ntimezone:America/New_York\n",
+			expectedRanges: []agerotate.Range{
+				{Age: 24 * time.Hour},
+				{Age: 168 * time.Hour, Period: agerotate.Day},
+				{Age: 1344 * time.Hour, Period: agerotate.Week},
+				{Age: 8760 * time.Hour, Period: agerotate.Month},
+				{Age: 87600 * time.Hour, Period: agerotate.Year},
+			},
+			expectedLocation: "America/New_York",
+		},
+		{
+			id:          "Out of order",
+			input:       "pathglob:/x/*\nweekly:1344h\ndaily:168h\n",
+			expectedErr: "Line 3: Age value must be larger than previous age value",
+		},
+		{
+			id:          "Too many values",
+			input:       "pathglob:/x/*\ndaily:168h:1h\n",
+			expectedErr: "Line 2: Daily lines must have one value",
+		},
+		{
+			id:          "Unknown time zone",
+			input:       "pathglob:/x/*\ndaily:168h\ntimezone:Nowhere/Special\n",
+			expectedErr: "Line 3: Invalid timezone: unknown time zone Nowhere/Special",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(job.Ranges) != len(tc.expectedRanges) {
+			t.Fatalf("Expected ranges %v, got %v", tc.expectedRanges, job.Ranges)
+		}
+		for i := range tc.expectedRanges {
+			if tc.expectedRanges[i] != job.Ranges[i] {
+				t.Fatalf("Expected range %d to be %q, got %q", i, tc.expectedRanges[i], job.Ranges[i])
+			}
+		}
+		if job.Cleaner.Location == nil || job.Cleaner.Location.String() != tc.expectedLocation {
+			t.Fatalf("Expected location %q, got %v", tc.expectedLocation, job.Cleaner.Location)
+		}
+	}
+}
+
+func TestAlign(t *testing.T) {
+	for _, tc := range []struct {
+		id             string
+		input          string
+		expectedErr    string
+		expectedRanges []agerotate.Range
+		expectedAnchor time.Time
+	}{
+		{
+			id:    "Epoch",
+			input: "pathglob:/x/*\nkeeplast:2\nrange:24h:0\nalign:epoch\nrange:168h:6h\ndaily:720h\n",
+			expectedRanges: []agerotate.Range{
+				{Count: 2},
+				{Age: 24 * time.Hour, Aligned: true},
+				{Age: 168 * time.Hour, Interval: 6 * time.Hour, Aligned: true},
+				{Age: 720 * time.Hour, Period: agerotate.Day},
+			},
+		},
+		{
+			id:             "Anchor date in time zone",
+			input:          "pathglob:/x/*\nALIGN:2026-01-01\nrange:168h:6h\ntimezone:UTC\n",
+			expectedRanges: []agerotate.Range{{Age: 168 * time.Hour, Interval: 6 * time.Hour, Aligned: true}},
+			expectedAnchor: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			id:          "Bad anchor",
+			input:       "pathglob:/x/*\nalign:tuesday\n",
+			expectedErr: "Line 2: Align value must be \"epoch\" or a date like \"2006-01-02\", got \"tuesday\"",
+		},
+		{
+			id:             "With slot selector",
+			input:          "pathglob:/x/*\nalign:epoch\nselect:midpoint\nrange:168h:6h\n",
+			expectedRanges: []agerotate.Range{{Age: 168 * time.Hour, Interval: 6 * time.Hour, Aligned: true, Selector: bucket.KeepMidpoint{}}},
+		},
+		{
+			id:          "With youngest selector",
+			input:       "pathglob:/x/*\nselect:youngest\nrange:168h:4h\nalign:epoch\n",
+			expectedErr: "Line 2: Selector \"youngest\" would replace ALIGN; only midpoint and largest keep one file per aligned slot",
+		},
+		{
+			id:          "With oldest selector",
+			input:       "pathglob:/x/*\nalign:2026-01-01\nrange:168h:4h\nselect:oldest\n",
+			expectedErr: "Line 4: Selector \"oldest\" would replace ALIGN; only midpoint and largest keep one file per aligned slot",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(job.Ranges) != len(tc.expectedRanges) {
+			t.Fatalf("Expected ranges %v, got %v", tc.expectedRanges, job.Ranges)
+		}
+		for i := range tc.expectedRanges {
+			if tc.expectedRanges[i] != job.Ranges[i] {
+				t.Fatalf("Expected range %d to be %+v, got %+v", i, tc.expectedRanges[i], job.Ranges[i])
+			}
+		}
+		if !job.Cleaner.Anchor.Equal(tc.expectedAnchor) {
+			t.Fatalf("Expected anchor %v, got %v", tc.expectedAnchor, job.Cleaner.Anchor)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	for _, tc := range []struct {
+		id             string
+		input          string
+		expectedErr    string
+		expectedRanges []agerotate.Range
+	}{
+		{
+			id:    "Oldest",
+			input: "pathglob:/x/*\nkeeplast:2\nrange:24h:0\nSELECT:Oldest\nrange:168h:6h\ndaily:720h\n",
+			expectedRanges: []agerotate.Range{
+				{Count: 2},
+				{Age: 24 * time.Hour, Selector: bucket.KeepOldest{}},
+				{Age: 168 * time.Hour, Interval: 6 * time.Hour, Selector: bucket.KeepOldest{}},
+				{Age: 720 * time.Hour, Period: agerotate.Day},
+			},
+		},
+		{
+			id:          "Unknown",
+			input:       "pathglob:/x/*\nselect:random\n",
+			expectedErr: "Line 2: Unknown selector \"random\"",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(job.Ranges) != len(tc.expectedRanges) {
+			t.Fatalf("Expected ranges %v, got %v", tc.expectedRanges, job.Ranges)
+		}
+		for i := range tc.expectedRanges {
+			if tc.expectedRanges[i] != job.Ranges[i] {
+				t.Fatalf("Expected range %d to be %+v, got %+v", i, tc.expectedRanges[i], job.Ranges[i])
+			}
+		}
+	}
+}
+
+func TestNameTimeDirectives(t *testing.T) {
+	for _, tc := range []struct {
+		id                string
+		input             string
+		expectedErr       string
+		expectedNameTime  string
+		expectedUnmatched fileobject.Unmatched
+	}{
+		{
+			id:                "Strftime with separator",
+			input:             "pathglob:/x/*\nrange:24h:0\ntimezone:UTC\nnametime:db-%Y-%m-%dT%H:%M\nnameunmatched:MTIME\n",
+			expectedNameTime:  `db-(?P<year>\d{4})-(?P<month>\d{2})-(?P<day>\d{2})T(?P<hour>\d{2}):(?P<minute>\d{2})`,
+			expectedUnmatched: fileobject.UnmatchedMTime,
+		},
+		{
+			id:                "Regex",
+			input:             "pathglob:/x/*\nrange:24h:0\nnameregex:^(?P<unix>\\d+)\\.log$\n",
+			expectedNameTime:  `^(?P<unix>\d+)\.log$`,
+			expectedUnmatched: fileobject.UnmatchedSkip,
+		},
+		{
+			id:          "Both",
+			input:       "pathglob:/x/*\nrange:24h:0\nnametime:%Y\nnameregex:(?P<year>\\d+)\n",
+			expectedErr: "Line 4: Duplicate name timestamp specification",
+		},
+		{
+			id:          "Bad layout",
+			input:       "pathglob:/x/*\nnametime:%Q\nrange:24h:0\n",
+			expectedErr: "Line 2: Unsupported conversion %Q in \"%Q\"",
+		},
+		{
+			id:          "Bad policy",
+			input:       "pathglob:/x/*\nrange:24h:0\nnametime:%Y\nnameunmatched:delete\n",
+			expectedErr: "Line 4: Nameunmatched must be skip, mtime or error, got \"delete\"",
+		},
+		{
+			id:          "Policy without name timestamp",
+			input:       "pathglob:/x/*\nrange:24h:0\nnameunmatched:skip\n",
+			expectedErr: "Line 3: Nameunmatched requires NAMETIME or NAMEREGEX",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if job.Files.NameTime == nil || job.Files.NameTime.String() != tc.expectedNameTime {
+			t.Fatalf("Expected name timestamp %q, got %v", tc.expectedNameTime, job.Files.NameTime)
+		}
+		if job.Files.Unmatched != tc.expectedUnmatched {
+			t.Fatalf("Expected unmatched policy %v, got %v", tc.expectedUnmatched, job.Files.Unmatched)
+		}
+	}
+}
+
+func TestParseJobs(t *testing.T) {
+	for _, tc := range []struct {
+		id            string
+		input         string
+		expectedErr   string
+		expectedNames []string
+		expectedPaths []string
+	}{
+		{
+			id:            "No stanzas",
+			input:         "pathglob:/a/*\nrange:1h:0\n",
+			expectedNames: []string{""},
+			expectedPaths: []string{"/a/*"},
+		},
+		{
+			id:            "Two stanzas",
+			input:         "# Dumps\njob:dumps\npathglob:/a/*\nrange:1h:0\nminkeep:3\n\nJOB:logs\npathglob:/b/*\nrange:2h:0\nminkeep:4\n",
+			expectedNames: []string{"dumps", "logs"},
+			expectedPaths: []string{"/a/*", "/b/*"},
+		},
+		{
+			id:          "Directive before first stanza",
+			input:       "minkeep:3\njob:dumps\npathglob:/a/*\nrange:1h:0\n",
+			expectedErr: "Line 2: Every directive must be inside a job once JOB is used",
+		},
+		{
+			id:          "Duplicate name",
+			input:       "job:dumps\npathglob:/a/*\nrange:1h:0\njob:dumps\npathglob:/b/*\nrange:1h:0\n",
+			expectedErr: "Line 4: Duplicate job \"dumps\"",
+		},
+		{
+			id:          "Incomplete stanza",
+			input:       "job:dumps\npathglob:/a/*\njob:logs\npathglob:/b/*\nrange:1h:0\n",
+			expectedErr: "Job \"dumps\": No ranges specified",
+		},
+		{
+			id:          "Error inside stanza",
+			input:       "job:dumps\npathglob:/a/*\nrange:1h:0\njob:logs\npathglob:/b/*\nrange:1h\n",
+			expectedErr: "Line 6: Range lines must have two values",
+		},
+		{
+			id:          "Missing name",
+			input:       "job:\n",
+			expectedErr: "Line 1: Job lines must have one value",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		jobs, err := ParseJobs(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(jobs) != len(tc.expectedNames) {
+			t.Fatalf("Expected %d jobs, got %d", len(tc.expectedNames), len(jobs))
+		}
+		for i, job := range jobs {
+			if job.Name != tc.expectedNames[i] || job.Files.Pattern != tc.expectedPaths[i] {
+				t.Fatalf("Expected job %q for %q, got %q for %q", tc.expectedNames[i], tc.expectedPaths[i], job.Name, job.Files.Pattern)
+			}
+		}
+	}
+
+	multi := "job:dumps\npathglob:/a/*\nrange:1h:0\njob:logs\npathglob:/b/*\nrange:1h:0\n"
+	expected := "Config has 2 jobs, expected one"
+	if _, err := ParseJob(strings.NewReader(multi), ":"); err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestDurationUnits(t *testing.T) {
+	for _, tc := range []struct {
+		id             string
+		input          string
+		expectedErr    string
+		expectedRanges []agerotate.Range
+	}{
+		{
+			id:    "Hours",
+			input: "pathglob:/x/*\nrange:72h:0\nrange:4320h:24h\n",
+			expectedRanges: []agerotate.Range{
+				{Age: 72 * time.Hour},
+				{Age: 4320 * time.Hour, Interval: 24 * time.Hour},
+			},
+		},
+		{
+			id:    "Friendly units",
+			input: "pathglob:/x/*\nrange:3d:0\nrange:2w:12h\nrange:6mo:1d\nmonthly:1y\nyearly:10y\n",
+			expectedRanges: []agerotate.Range{
+				{Age: 72 * time.Hour},
+				{Age: 336 * time.Hour, Interval: 12 * time.Hour},
+				{Age: 4320 * time.Hour, Interval: 24 * time.Hour},
+				{Age: 8760 * time.Hour, Period: agerotate.Month},
+				{Age: 87600 * time.Hour, Period: agerotate.Year},
+			},
+		},
+		{
+			id:          "Bad unit",
+			input:       "pathglob:/x/*\nrange:3d:1q\n",
+			expectedErr: "Line 2: Invalid interval: time: unknown unit \"q\" in duration \"1q\"",
+		},
+		{
+			id:          "Bad friendly duration",
+			input:       "pathglob:/x/*\nrange:1d2:0\n",
+			expectedErr: "Line 2: Invalid age: Invalid duration \"1d2\"",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(job.Ranges) != len(tc.expectedRanges) {
+			t.Fatalf("Expected ranges %v, got %v", tc.expectedRanges, job.Ranges)
+		}
+		for i := range tc.expectedRanges {
+			if tc.expectedRanges[i] != job.Ranges[i] {
+				t.Fatalf("Expected range %d to be %q, got %q", i, tc.expectedRanges[i], job.Ranges[i])
+			}
+		}
+	}
+}
+
+// writeConfigs writes each config in files to dir, creating subdirectories as needed.
+func writeConfigs(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Error creating %q: %v", filepath.Dir(path), err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing %q: %v", path, err)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	for _, tc := range []struct {
+		id              string
+		files           map[string]string
+		expectedErr     string
+		expectedMinKeep int
+		expectedRanges  []agerotate.Range
+		expectedNames   []string
+	}{
+		{
+			id: "Shared defaults",
+			files: map[string]string{
+				"main.conf":            "include:common/defaults.conf\npathglob:/a/*\nrange:1h:0\n",
+				"common/defaults.conf": "minkeep:3\nrange:30m:0\n",
+			},
+			expectedMinKeep: 3,
+			expectedRanges:  []agerotate.Range{{Age: 30 * time.Minute}, {Age: time.Hour}},
+			expectedNames:   []string{""},
+		},
+		{
+			id: "Override after include",
+			files: map[string]string{
+				"main.conf":     "include:defaults.conf\nminkeep:7\npathglob:/a/*\nrange:1h:0\n",
+				"defaults.conf": "minkeep:3\n",
+			},
+			expectedMinKeep: 7,
+			expectedRanges:  []agerotate.Range{{Age: time.Hour}},
+			expectedNames:   []string{""},
+		},
+		{
+			id: "Override before include",
+			files: map[string]string{
+				"main.conf":     "minkeep:7\ninclude:defaults.conf\npathglob:/a/*\nrange:1h:0\n",
+				"defaults.conf": "minkeep:3\n",
+			},
+			expectedErr: "Line 1 of DIR/defaults.conf: Duplicate minkeep specification",
+		},
+		{
+			id: "Glob of jobs",
+			files: map[string]string{
+				"main.conf":        "include:jobs.d/*.conf\n",
+				"jobs.d/b.conf":    "job:b\npathglob:/b/*\nrange:1h:0\n",
+				"jobs.d/a.conf":    "job:a\npathglob:/a/*\nrange:1h:0\n",
+				"jobs.d/notes.txt": "not a config\n",
+			},
+			expectedRanges: []agerotate.Range{{Age: time.Hour}},
+			expectedNames:  []string{"a", "b"},
+		},
+		{
+			id: "Empty glob",
+			files: map[string]string{
+				"main.conf": "include:none/*.conf\npathglob:/a/*\nrange:1h:0\n",
+			},
+			expectedRanges: []agerotate.Range{{Age: time.Hour}},
+			expectedNames:  []string{""},
+		},
+		{
+			id: "Missing file",
+			files: map[string]string{
+				"main.conf": "pathglob:/a/*\ninclude:missing.conf\n",
+			},
+			expectedErr: "Line 2: Included config \"DIR/missing.conf\" does not exist",
+		},
+		{
+			id: "Error in included file",
+			files: map[string]string{
+				"main.conf":  "pathglob:/a/*\ninclude:other.conf\n",
+				"other.conf": "# Ranges\nrange:1h\n",
+			},
+			expectedErr: "Line 2 of DIR/other.conf: Range lines must have two values",
+		},
+		{
+			id: "Cycle",
+			files: map[string]string{
+				"main.conf": "pathglob:/a/*\ninclude:a.conf\n",
+				"a.conf":    "include:b.conf\n",
+				"b.conf":    "include:a.conf\n",
+			},
+			expectedErr: "Line 1 of DIR/b.conf: Include cycle: DIR/a.conf -> DIR/b.conf -> DIR/a.conf",
+		},
+		{
+			id: "Including itself",
+			files: map[string]string{
+				"main.conf": "include:main.conf\n",
+			},
+			expectedErr: "Line 1: Include cycle: DIR/main.conf -> DIR/main.conf",
+		},
+		{
+			id: "JSON",
+			files: map[string]string{
+				"main.conf":  "include:other.json\n",
+				"other.json": "{}",
+			},
+			expectedErr: "Line 1: Can't include JSON config \"DIR/other.json\"",
+		},
+		{
+			id: "Too many values",
+			files: map[string]string{
+				"main.conf": "include:a:b\n",
+			},
+			expectedErr: "Line 1: Include lines must have one value",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		dir, err := ioutil.TempDir("", "config")
+		if err != nil {
+			t.Fatalf("Error creating temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		writeConfigs(t, dir, tc.files)
+
+		jobs, err := ParseFile(filepath.Join(dir, "main.conf"), ":")
+		if tc.expectedErr != "" {
+			expectedErr := strings.Replace(tc.expectedErr, "DIR", dir, -1)
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", expectedErr)
+			}
+			if expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(jobs) != len(tc.expectedNames) {
+			t.Fatalf("Expected %d jobs, got %d", len(tc.expectedNames), len(jobs))
+		}
+		for i, job := range jobs {
+			if job.Name != tc.expectedNames[i] {
+				t.Fatalf("Expected job %q, got %q", tc.expectedNames[i], job.Name)
+			}
+		}
+		if jobs[0].Cleaner.MinKeep != tc.expectedMinKeep {
+			t.Fatalf("Expected minkeep %d, got %d", tc.expectedMinKeep, jobs[0].Cleaner.MinKeep)
+		}
+		if len(jobs[0].Ranges) != len(tc.expectedRanges) {
+			t.Fatalf("Expected ranges %v, got %v", tc.expectedRanges, jobs[0].Ranges)
+		}
+		for i := range tc.expectedRanges {
+			if tc.expectedRanges[i] != jobs[0].Ranges[i] {
+				t.Fatalf("Expected range %d to be %q, got %q", i, tc.expectedRanges[i], jobs[0].Ranges[i])
+			}
+		}
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	for _, tc := range []struct {
+		id            string
+		files         map[string]string
+		expectedErr   string
+		expectedNames []string
+		expectedPaths []string
+	}{
+		{
+			id: "One job per file",
+			files: map[string]string{
+				"logs.conf":    "pathglob:/b/*\nrange:1h:0\n",
+				"dumps.json":   `{"jobs": [{"pathglob": "/a/*", "ranges": [{"age": "1h"}]}]}`,
+				"multi.conf":   "job:x\npathglob:/x/*\nrange:1h:0\njob:y\npathglob:/y/*\nrange:1h:0\n",
+				".hidden.conf": "garbage\n",
+				"README":       "garbage\n",
+				"sub/c.conf":   "garbage\n",
+			},
+			expectedNames: []string{"dumps", "logs", "x", "y"},
+			expectedPaths: []string{"/a/*", "/b/*", "/x/*", "/y/*"},
+		},
+		{
+			id: "Error names the file",
+			files: map[string]string{
+				"a.conf": "pathglob:/a/*\nrange:1h\n",
+			},
+			expectedErr: "Line 2 of DIR/a.conf: Range lines must have two values",
+		},
+		{
+			id: "JSON error names the file",
+			files: map[string]string{
+				"a.json": `{"jobs": [{"pathglob": "/a/*", "minkeep": -1}]}`,
+			},
+			expectedErr: "DIR/a.json: jobs[0].minkeep: Minkeep must be positive, got -1",
+		},
+		{
+			id: "Duplicate job across files",
+			files: map[string]string{
+				"a.conf": "job:b\npathglob:/a/*\nrange:1h:0\n",
+				"b.conf": "pathglob:/b/*\nrange:1h:0\n",
+			},
+			expectedErr: "DIR/b.conf: Duplicate job \"b\"",
+		},
+		{
+			id:          "No configs",
+			files:       map[string]string{"README": "hello\n"},
+			expectedErr: "No .conf or .json configs in \"DIR\"",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		dir, err := ioutil.TempDir("", "config")
+		if err != nil {
+			t.Fatalf("Error creating temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		writeConfigs(t, dir, tc.files)
+
+		jobs, err := ParseDir(dir, ":")
+		if tc.expectedErr != "" {
+			expectedErr := strings.Replace(tc.expectedErr, "DIR", dir, -1)
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", expectedErr)
+			}
+			if expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(jobs) != len(tc.expectedNames) {
+			t.Fatalf("Expected %d jobs, got %d", len(tc.expectedNames), len(jobs))
+		}
+		for i, job := range jobs {
+			if job.Name != tc.expectedNames[i] || job.Files.Pattern != tc.expectedPaths[i] {
+				t.Fatalf("Expected job %q for %q, got %q for %q", tc.expectedNames[i], tc.expectedPaths[i], job.Name, job.Files.Pattern)
+			}
+		}
+	}
+}
+
+func TestExclude(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		input       string
+		expectedErr string
+		excluded    []string
+		included    []string
+	}{
+		{
+			id:       "Glob and regex",
+			input:    "pathglob:/backup/*/*.bz2\nexclude:keep\nexcluderegex:\\.partial\\.bz2$\n",
+			excluded: []string{"/backup/keep/a.bz2", "/backup/db/a.partial.bz2"},
+			included: []string{"/backup/db/a.bz2", "/backup/keeper/a.bz2"},
+		},
+		{
+			id:       "Field separator in regex",
+			input:    "pathglob:/backup/*\nexcluderegex:^/backup/[^:]+:old$\n",
+			excluded: []string{"/backup/db:old"},
+			included: []string{"/backup/db:new"},
+		},
+		{
+			id:          "Empty",
+			input:       "pathglob:/backup/*\nexclude:\n",
+			expectedErr: "Line 2: Must specify exclude",
+		},
+		{
+			id:          "Bad glob",
+			input:       "pathglob:/backup/*\nexclude:[a-\n",
+			expectedErr: "Line 2: Invalid exclude \"[a-\": syntax error in pattern",
+		},
+		{
+			id:          "Bad regex",
+			input:       "pathglob:/backup/*\nexcluderegex:(a\n",
+			expectedErr: "Line 2: Invalid excluderegex \"(a\": error parsing regexp: missing closing ): `(a`",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input+"range:1h:0\n"), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		for _, path := range tc.excluded {
+			if fileobject.Excluded(job.Files.Exclude, path) == nil {
+				t.Fatalf("Expected %q to be excluded", path)
+			}
+		}
+		for _, path := range tc.included {
+			if e := fileobject.Excluded(job.Files.Exclude, path); e != nil {
+				t.Fatalf("Expected %q to be included, excluded by %q", path, e)
+			}
+		}
+	}
+}
+
+func TestWalkDirectives(t *testing.T) {
+	for _, tc := range []struct {
+		id             string
+		input          string
+		expectedErr    string
+		expectedFollow bool
+		expectedDepth  int
+		expectedRemove bool
+	}{
+		{
+			id:    "Defaults",
+			input: "pathglob:/x/**/*.gz\nrange:24h:0\n",
+		},
+		{
+			id:          "Not a boolean",
+			input:       "pathglob:/x/**/*.gz\nrange:24h:0\nremoveemptydirs:yes\n",
+			expectedErr: "Line 3: Removeemptydirs must be true or false, got \"yes\"",
+		},
+		{
+			id:             "All set",
+			input:          "pathglob:/x/**/*.gz\nrange:24h:0\nfollowsymlinks:true\nMAXDEPTH:3\nremoveemptydirs:1\n",
+			expectedFollow: true,
+			expectedDepth:  3,
+			expectedRemove: true,
+		},
+		{
+			id:             "Remove empty directories without **",
+			input:          "pathglob:/x/*/*.gz\nrange:24h:0\nremoveemptydirs:true\n",
+			expectedRemove: true,
+		},
+		{
+			id:          "Follow without **",
+			input:       "pathglob:/x/*/*.gz\nrange:24h:0\nfollowsymlinks:true\n",
+			expectedErr: "Line 1: FOLLOWSYMLINKS and MAXDEPTH require a ** element in the path",
+		},
+		{
+			id:          "Depth without **",
+			input:       "range:24h:0\nmaxdepth:2\npathglob:/x/*.gz\n",
+			expectedErr: "Line 3: FOLLOWSYMLINKS and MAXDEPTH require a ** element in the path",
+		},
+		{
+			id:          "Zero depth",
+			input:       "pathglob:/x/**/*.gz\nmaxdepth:0\n",
+			expectedErr: "Line 2: Maxdepth must be at least 1, got 0",
+		},
+		{
+			id:          "Duplicate",
+			input:       "pathglob:/x/**/*.gz\nfollowsymlinks:true\nfollowsymlinks:false\n",
+			expectedErr: "Line 3: Duplicate followsymlinks specification",
+		},
+		{
+			id:          "Two values",
+			input:       "pathglob:/x/**/*.gz\nremoveemptydirs:true:false\n",
+			expectedErr: "Line 2: Removeemptydirs lines must have one value",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		job, err := ParseJob(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if job.Files.FollowSymlinks != tc.expectedFollow || job.Files.MaxDepth != tc.expectedDepth || job.Files.RemoveEmptyDirs != tc.expectedRemove {
+			t.Fatalf("Expected follow %v, depth %d and remove %v, got %v, %d and %v", tc.expectedFollow, tc.expectedDepth, tc.expectedRemove, job.Files.FollowSymlinks, job.Files.MaxDepth, job.Files.RemoveEmptyDirs)
+		}
+	}
+}
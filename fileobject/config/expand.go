@@ -0,0 +1,109 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefinePrefix is the directive that defines a variable for ${NAME} expansion.
+const DefinePrefix = "define"
+
+// variableName matches the names variables may have.
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// expand replaces each ${NAME} in s with the variable's value from a DEFINE line or, failing that, the environment, and each $$ with $. A $ followed by anything else is left alone. Errors don't include the position, which the caller adds.
+func (p *parser) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var out strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		out.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			out.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("Unterminated ${ in %q", s[i:])
+			}
+			name := s[i+2 : i+end]
+			value, err := p.lookup(name)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(value)
+			s = s[i+end+1:]
+		default:
+			out.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
+
+// lookup returns the value of a variable, preferring DEFINE lines to the environment.
+func (p *parser) lookup(name string) (string, error) {
+	if !variableName.MatchString(name) {
+		return "", fmt.Errorf("Invalid variable name %q", name)
+	}
+	if value, ok := p.defines[name]; ok {
+		return value, nil
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("Undefined variable %q", name)
+}
+
+// expandAll expands each of values, adding the position to errors.
+func (p *parser) expandAll(values []string) ([]string, error) {
+	expanded := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		if expanded[i], err = p.expand(v); err != nil {
+			return nil, fmt.Errorf("%v: %v", p.pos(), err)
+		}
+	}
+	return expanded, nil
+}
+
+// define handles a DEFINE line, whose values have already been expanded. The value may contain the field separator. A variable can only be defined once per config.
+func (p *parser) define(values []string) error {
+	if len(values) < 2 {
+		return fmt.Errorf("%v: Define lines must have a name and a value", p.pos())
+	}
+	return p.setDefine(values[0], strings.Join(values[1:], p.fieldSep))
+}
+
+// setDefine records a variable from a DEFINE line or a structured config's definitions.
+func (p *parser) setDefine(name, value string) error {
+	if !variableName.MatchString(name) {
+		return fmt.Errorf("%v: Invalid variable name %q", p.pos(), name)
+	}
+	if _, ok := p.defines[name]; ok {
+		return fmt.Errorf("%v: Duplicate definition of %q", p.pos(), name)
+	}
+	if p.defines == nil {
+		p.defines = map[string]string{}
+	}
+	p.defines[name] = value
+	return nil
+}
@@ -0,0 +1,133 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	os.Setenv("AGEROTATE_TEST_ROOT", "/srv/backup")
+	os.Setenv("AGEROTATE_TEST_KEEP", "2w")
+	os.Setenv("AGEROTATE_TEST_EMPTY", "")
+	defer os.Unsetenv("AGEROTATE_TEST_ROOT")
+	defer os.Unsetenv("AGEROTATE_TEST_KEEP")
+	defer os.Unsetenv("AGEROTATE_TEST_EMPTY")
+
+	for _, tc := range []struct {
+		id            string
+		input         string
+		expectedErr   string
+		expectedNames []string
+		expectedPaths []string
+		expectedAge   time.Duration
+	}{
+		{
+			id:            "Environment",
+			input:         "pathglob:${AGEROTATE_TEST_ROOT}/dumps/*\nrange:${AGEROTATE_TEST_KEEP}:0\n",
+			expectedNames: []string{""},
+			expectedPaths: []string{"/srv/backup/dumps/*"},
+			expectedAge:   336 * time.Hour,
+		},
+		{
+			id:            "Define",
+			input:         "define:DUMPS:${AGEROTATE_TEST_ROOT}/dumps\njob:db-${AGEROTATE_TEST_KEEP}\npathglob:${DUMPS}/*.gz\nrange:1d:0\n",
+			expectedNames: []string{"db-2w"},
+			expectedPaths: []string{"/srv/backup/dumps/*.gz"},
+			expectedAge:   24 * time.Hour,
+		},
+		{
+			id:            "Define shadows the environment",
+			input:         "DEFINE:AGEROTATE_TEST_ROOT:/local\npathglob:${AGEROTATE_TEST_ROOT}/*\nrange:1d:0\n",
+			expectedNames: []string{""},
+			expectedPaths: []string{"/local/*"},
+			expectedAge:   24 * time.Hour,
+		},
+		{
+			id:            "Value with the field separator",
+			input:         "define:ROOT:C:/backup\npathglob:${ROOT}/*${AGEROTATE_TEST_EMPTY}\nrange:1d:0\n",
+			expectedNames: []string{""},
+			expectedPaths: []string{"C:/backup/*"},
+			expectedAge:   24 * time.Hour,
+		},
+		{
+			id:            "Dollars",
+			input:         "pathglob:/a/$$x-$y-$\nrange:1d:0\n",
+			expectedNames: []string{""},
+			expectedPaths: []string{"/a/$x-$y-$"},
+			expectedAge:   24 * time.Hour,
+		},
+		{
+			id:          "Undefined",
+			input:       "pathglob:${AGEROTATE_TEST_MISSING}/*\nrange:1d:0\n",
+			expectedErr: "Line 1: Undefined variable \"AGEROTATE_TEST_MISSING\"",
+		},
+		{
+			id:          "Unterminated",
+			input:       "pathglob:/a/*\nrange:${AGEROTATE_TEST_KEEP:0\n",
+			expectedErr: "Line 2: Unterminated ${ in \"${AGEROTATE_TEST_KEEP\"",
+		},
+		{
+			id:          "Invalid name",
+			input:       "pathglob:${1ROOT}/*\n",
+			expectedErr: "Line 1: Invalid variable name \"1ROOT\"",
+		},
+		{
+			id:          "Invalid define name",
+			input:       "define:A-B:1\n",
+			expectedErr: "Line 1: Invalid variable name \"A-B\"",
+		},
+		{
+			id:          "Duplicate define",
+			input:       "define:A:1\ndefine:A:2\n",
+			expectedErr: "Line 2: Duplicate definition of \"A\"",
+		},
+		{
+			id:          "Define without value",
+			input:       "define:A\n",
+			expectedErr: "Line 1: Define lines must have a name and a value",
+		},
+		{
+			id:          "Use before define",
+			input:       "pathglob:${LATER}/*\ndefine:LATER:/a\n",
+			expectedErr: "Line 1: Undefined variable \"LATER\"",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		jobs, err := ParseJobs(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(jobs) != len(tc.expectedNames) {
+			t.Fatalf("Expected %d jobs, got %d", len(tc.expectedNames), len(jobs))
+		}
+		for i, job := range jobs {
+			if job.Name != tc.expectedNames[i] || job.Files.Pattern != tc.expectedPaths[i] {
+				t.Fatalf("Expected job %q for %q, got %q for %q", tc.expectedNames[i], tc.expectedPaths[i], job.Name, job.Files.Pattern)
+			}
+		}
+		if jobs[0].Ranges[0].Age != tc.expectedAge {
+			t.Fatalf("Expected age %v, got %v", tc.expectedAge, jobs[0].Ranges[0].Age)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// Keys of a range in a JSON config.
+const (
+	AgeKey      = "age"
+	IntervalKey = "interval"
+	PeriodKey   = "period"
+)
+
+// Keys of a JSON config and of a job in it, other than the directives in jsonDirectives.
+const (
+	DefineKey = "define"
+	JobsKey   = "jobs"
+	NameKey   = "name"
+	RangesKey = "ranges"
+)
+
+// jsonDirectives are the directives that can be used as keys of a job in a JSON config. Each takes a single string, number or boolean, just like the directive of the same name in the line format.
+var jsonDirectives = map[string]bool{
+	PathPrefix:             true,
+	MinKeepPrefix:          true,
+	MaxDeletePrefix:        true,
+	MaxDeletePercentPrefix: true,
+	MaxBytesPrefix:         true,
+	KeepLastPrefix:         true,
+	TimeZonePrefix:         true,
+	AlignPrefix:            true,
+	SelectPrefix:           true,
+	NameTimePrefix:         true,
+	NameRegexPrefix:        true,
+	NameUnmatchedPrefix:    true,
+	ExcludePrefix:          true,
+	ExcludeRegexPrefix:     true,
+	FollowSymlinksPrefix:   true,
+	MaxDepthPrefix:         true,
+	RemoveEmptyDirsPrefix:  true,
+}
+
+// jsonListDirectives are the directives in jsonDirectives that may also be given a list of values, each applied in turn like a repeated line.
+var jsonListDirectives = map[string]bool{
+	ExcludePrefix:      true,
+	ExcludeRegexPrefix: true,
+}
+
+// ParseJobsJSON reads and parses a JSON config. The config is an object with a "jobs" list. Each job is an object whose keys are the names of the line format's directives in lower case, such as "pathglob" and "minkeep", with a string, number or boolean value, or a list of them for directives that may be repeated such as "exclude", plus an optional "name" and a "ranges" list. Each range is an object with an "age" and either an "interval" or a "period" of "daily", "weekly", "monthly" or "yearly". An optional "define" object sets variables for ${NAME} expansion in every value, like DEFINE lines. Errors name the key at fault, such as jobs[0].ranges[1].
+func ParseJobsJSON(in io.Reader) ([]Job, error) {
+	p := newParser(in, "")
+	if err := p.parseJSON(in); err != nil {
+		return nil, err
+	}
+	return p.jobs, nil
+}
+
+// parseJSON parses a JSON config, applying each key to the job being parsed just as parse applies each line.
+func (p *parser) parseJSON(in io.Reader) error {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			return fmt.Errorf("Line %d: %v", bytes.Count(data[:syntaxErr.Offset], []byte("\n"))+1, err)
+		}
+		return fmt.Errorf("Config must be an object with a %q list", JobsKey)
+	}
+	for _, key := range sortedKeys(doc) {
+		if key != JobsKey && key != DefineKey {
+			return fmt.Errorf("%s: Unknown key", key)
+		}
+	}
+	if raw, ok := doc[DefineKey]; ok {
+		if err := p.parseJSONDefines(raw); err != nil {
+			return err
+		}
+	}
+	var jobs []map[string]json.RawMessage
+	if err := json.Unmarshal(doc[JobsKey], &jobs); err != nil || len(jobs) == 0 {
+		return fmt.Errorf("%s: Must be a list of one or more jobs", JobsKey)
+	}
+	for i, job := range jobs {
+		if err := p.parseJSONJob(fmt.Sprintf("%s[%d]", JobsKey, i), job, len(jobs) > 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseJSONDefines sets the variables in a JSON config's define object. They're read in the order they're written so that each may refer to those before it.
+func (p *parser) parseJSONDefines(raw json.RawMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("%s: Must be an object of variables", DefineKey)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("%s: %v", DefineKey, err)
+		}
+		name := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("%s: %v", DefineKey, err)
+		}
+		p.key = DefineKey + "." + name
+		expanded, err := p.jsonValue(value)
+		if err != nil {
+			return err
+		}
+		if err := p.setDefine(name, expanded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseJSONJob parses one job from a JSON config. The name is required if needName is set.
+func (p *parser) parseJSONJob(key string, job map[string]json.RawMessage, needName bool) error {
+	p.jobState = jobState{ranges: []agerotate.Range{}}
+	if raw, ok := job[NameKey]; ok {
+		p.key = key + "." + NameKey
+		var err error
+		if p.name, err = p.jsonValue(raw); err != nil {
+			return err
+		}
+		if p.name == "" {
+			return fmt.Errorf("%s: Must not be empty", p.key)
+		}
+		if p.names[p.name] {
+			return fmt.Errorf("%s: Duplicate job %q", p.key, p.name)
+		}
+		p.names[p.name] = true
+	} else if needName {
+		return fmt.Errorf("%s: Every job needs a %q when there are several", key, NameKey)
+	}
+
+	for _, k := range sortedKeys(job) {
+		p.key = key + "." + k
+		switch {
+		case k == NameKey:
+		case k == RangesKey:
+			if err := p.parseJSONRanges(job[k]); err != nil {
+				return err
+			}
+		case jsonListDirectives[k] && isJSONList(job[k]):
+			if err := p.parseJSONList(k, job[k]); err != nil {
+				return err
+			}
+		case jsonDirectives[k]:
+			value, err := p.jsonValue(job[k])
+			if err != nil {
+				return err
+			}
+			if err := p.directive(k, []string{value}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: Unknown key", p.key)
+		}
+	}
+	p.key = key
+	return p.finishJob()
+}
+
+// parseJSONRanges parses the ranges list of the job being parsed, whose key is in p.key.
+func (p *parser) parseJSONRanges(raw json.RawMessage) error {
+	var ranges []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &ranges); err != nil {
+		return fmt.Errorf("%s: Must be a list of ranges", p.key)
+	}
+	listKey := p.key
+	for i, r := range ranges {
+		p.key = fmt.Sprintf("%s[%d]", listKey, i)
+		values := map[string]string{}
+		for _, k := range sortedKeys(r) {
+			if k != AgeKey && k != IntervalKey && k != PeriodKey {
+				return fmt.Errorf("%s.%s: Unknown key", p.key, k)
+			}
+			value, err := jsonScalar(r[k])
+			if err == nil {
+				value, err = p.expand(value)
+			}
+			if err != nil {
+				return fmt.Errorf("%s.%s: %v", p.key, k, err)
+			}
+			values[k] = value
+		}
+		if _, ok := values[AgeKey]; !ok {
+			return fmt.Errorf("%s: Missing %q", p.key, AgeKey)
+		}
+
+		periodName, hasPeriod := values[PeriodKey]
+		if !hasPeriod {
+			interval, ok := values[IntervalKey]
+			if !ok {
+				interval = "0"
+			}
+			if err := p.addRange([]string{values[AgeKey], interval}); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := values[IntervalKey]; ok {
+			return fmt.Errorf("%s: A range can't have both an %q and a %q", p.key, IntervalKey, PeriodKey)
+		}
+		period, ok := periodPrefixes[periodName]
+		if !ok {
+			return fmt.Errorf("%s.%s: Unknown period %q, expected daily, weekly, monthly or yearly", p.key, PeriodKey, periodName)
+		}
+		if err := p.addPeriodRange(periodName, period, []string{values[AgeKey]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseJSONList applies directive to each value in a list, naming each as p.key[i] in errors.
+func (p *parser) parseJSONList(directive string, raw json.RawMessage) error {
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return fmt.Errorf("%s: Must be a list of strings", p.key)
+	}
+	listKey := p.key
+	for i, v := range values {
+		p.key = fmt.Sprintf("%s[%d]", listKey, i)
+		value, err := p.jsonValue(v)
+		if err != nil {
+			return err
+		}
+		if err := p.directive(directive, []string{value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isJSONList reports whether raw is a JSON array.
+func isJSONList(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// jsonValue returns a JSON string, number or boolean as a string after expanding variables, naming p.key in errors.
+func (p *parser) jsonValue(raw json.RawMessage) (string, error) {
+	value, err := jsonScalar(raw)
+	if err == nil {
+		value, err = p.expand(value)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", p.key, err)
+	}
+	return value, nil
+}
+
+// jsonScalar returns a JSON string, number or boolean as a string.
+func jsonScalar(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String(), nil
+	}
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return strconv.FormatBool(b), nil
+	}
+	return "", fmt.Errorf("Must be a string, number or boolean, got %s", strings.TrimSpace(string(raw)))
+}
+
+// sortedKeys returns the keys of m in order so that errors are reported consistently.
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,236 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJobsJSON(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		input       string
+		equivalent  string
+		expectedErr string
+	}{
+		{
+			id:         "Single job",
+			input:      `{"jobs": [{"pathglob": "/a/*", "minkeep": 3, "maxbytes": "10G", "ranges": [{"age": "1h"}, {"age": "168h", "interval": "12h"}, {"age": "2160h", "period": "monthly"}]}]}`,
+			equivalent: "pathglob:/a/*\nminkeep:3\nmaxbytes:10G\nrange:1h:0\nrange:168h:12h\nmonthly:2160h\n",
+		},
+		{
+			id:         "Named jobs",
+			input:      `{"jobs": [{"name": "dumps", "pathglob": "/a/*", "keeplast": 2, "ranges": [{"age": "1h", "interval": "0s"}]}, {"name": "logs", "pathglob": "/b/*", "select": "midpoint", "align": "epoch", "ranges": [{"age": "24h", "interval": "1h"}]}]}`,
+			equivalent: "job:dumps\npathglob:/a/*\nkeeplast:2\nrange:1h:0\njob:logs\npathglob:/b/*\nselect:midpoint\nalign:epoch\nrange:24h:1h\n",
+		},
+		{
+			id:         "Name timestamps",
+			input:      `{"jobs": [{"pathglob": "/a/*", "nametime": "dump-%Y%m%d.gz", "nameunmatched": "mtime", "timezone": "UTC", "ranges": [{"age": "1h"}]}]}`,
+			equivalent: "pathglob:/a/*\nnametime:dump-%Y%m%d.gz\nnameunmatched:mtime\ntimezone:UTC\nrange:1h:0\n",
+		},
+		{
+			id:         "Recursive glob options",
+			input:      `{"jobs": [{"pathglob": "/a/**/*.gz", "followsymlinks": true, "maxdepth": 3, "removeemptydirs": "false", "ranges": [{"age": "1h"}]}]}`,
+			equivalent: "pathglob:/a/**/*.gz\nfollowsymlinks:true\nmaxdepth:3\nremoveemptydirs:false\nrange:1h:0\n",
+		},
+		{
+			id:         "Variables",
+			input:      `{"define": {"ROOT": "/srv", "DUMPS": "${ROOT}/dumps", "KEEP": "1d"}, "jobs": [{"name": "${ROOT}", "pathglob": "${DUMPS}/*", "ranges": [{"age": "${KEEP}"}]}]}`,
+			equivalent: "job:/srv\npathglob:/srv/dumps/*\nrange:1d:0\n",
+		},
+		{
+			id:          "Undefined variable",
+			input:       `{"jobs": [{"pathglob": "/a/*", "ranges": [{"age": "${AGEROTATE_TEST_MISSING}"}]}]}`,
+			expectedErr: "jobs[0].ranges[0].age: Undefined variable \"AGEROTATE_TEST_MISSING\"",
+		},
+		{
+			id:          "Undefined variable in directive",
+			input:       `{"jobs": [{"pathglob": "${AGEROTATE_TEST_MISSING}/*"}]}`,
+			expectedErr: "jobs[0].pathglob: Undefined variable \"AGEROTATE_TEST_MISSING\"",
+		},
+		{
+			id:          "Bad define",
+			input:       `{"define": {"A": [1]}, "jobs": []}`,
+			expectedErr: "define.A: Must be a string, number or boolean, got [1]",
+		},
+		{
+			id:         "Excludes",
+			input:      `{"jobs": [{"pathglob": "/a/*", "exclude": ["*.partial", "keep"], "excluderegex": "\\.tmp$", "ranges": [{"age": "1h"}]}]}`,
+			equivalent: "pathglob:/a/*\nexclude:*.partial\nexclude:keep\nexcluderegex:\\.tmp$\nrange:1h:0\n",
+		},
+		{
+			id:          "Bad exclude in list",
+			input:       `{"jobs": [{"pathglob": "/a/*", "exclude": ["*.partial", 3, "[a-"]}]}`,
+			expectedErr: "jobs[0].exclude[2]: Invalid exclude \"[a-\": syntax error in pattern",
+		},
+		{
+			id:          "Syntax error",
+			input:       "{\"jobs\": [\n{\"pathglob\": \"/a/*\",}\n]}",
+			expectedErr: "Line 2: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			id:          "Not an object",
+			input:       `[]`,
+			expectedErr: "Config must be an object with a \"jobs\" list",
+		},
+		{
+			id:          "Unknown top level key",
+			input:       `{"jobs": [], "extra": 1}`,
+			expectedErr: "extra: Unknown key",
+		},
+		{
+			id:          "No jobs",
+			input:       `{"jobs": []}`,
+			expectedErr: "jobs: Must be a list of one or more jobs",
+		},
+		{
+			id:          "Unknown job key",
+			input:       `{"jobs": [{"pathglob": "/a/*", "minkep": 3}]}`,
+			expectedErr: "jobs[0].minkep: Unknown key",
+		},
+		{
+			id:          "Bad value type",
+			input:       `{"jobs": [{"pathglob": "/a/*", "minkeep": [3]}]}`,
+			expectedErr: "jobs[0].minkeep: Must be a string, number or boolean, got [3]",
+		},
+		{
+			id:          "Invalid directive value",
+			input:       `{"jobs": [{"pathglob": "/a/*", "minkeep": -1, "ranges": [{"age": "1h"}]}]}`,
+			expectedErr: "jobs[0].minkeep: Minkeep must be positive, got -1",
+		},
+		{
+			id:          "Invalid range",
+			input:       `{"jobs": [{"pathglob": "/a/*", "ranges": [{"age": "2h"}, {"age": "1h", "interval": "1m"}]}]}`,
+			expectedErr: "jobs[0].ranges[1]: Age value must be larger than previous age value",
+		},
+		{
+			id:          "Range without age",
+			input:       `{"jobs": [{"pathglob": "/a/*", "ranges": [{"interval": "1h"}]}]}`,
+			expectedErr: "jobs[0].ranges[0]: Missing \"age\"",
+		},
+		{
+			id:          "Interval and period",
+			input:       `{"jobs": [{"pathglob": "/a/*", "ranges": [{"age": "48h", "interval": "1h", "period": "daily"}]}]}`,
+			expectedErr: "jobs[0].ranges[0]: A range can't have both an \"interval\" and a \"period\"",
+		},
+		{
+			id:          "Unknown period",
+			input:       `{"jobs": [{"pathglob": "/a/*", "ranges": [{"age": "48h", "period": "hourly"}]}]}`,
+			expectedErr: "jobs[0].ranges[0].period: Unknown period \"hourly\", expected daily, weekly, monthly or yearly",
+		},
+		{
+			id:          "Missing ranges",
+			input:       `{"jobs": [{"pathglob": "/a/*"}]}`,
+			expectedErr: "jobs[0]: No ranges specified",
+		},
+		{
+			id:          "Missing name",
+			input:       `{"jobs": [{"name": "a", "pathglob": "/a/*", "ranges": [{"age": "1h"}]}, {"pathglob": "/b/*", "ranges": [{"age": "1h"}]}]}`,
+			expectedErr: "jobs[1]: Every job needs a \"name\" when there are several",
+		},
+		{
+			id:          "Duplicate name",
+			input:       `{"jobs": [{"name": "a", "pathglob": "/a/*", "ranges": [{"age": "1h"}]}, {"name": "a", "pathglob": "/b/*", "ranges": [{"age": "1h"}]}]}`,
+			expectedErr: "jobs[1].name: Duplicate job \"a\"",
+		},
+		{
+			id:          "Error in named job",
+			input:       `{"jobs": [{"name": "a", "pathglob": "/a/*"}]}`,
+			expectedErr: "Job \"a\": No ranges specified",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		jobs, err := ParseJobsJSON(strings.NewReader(tc.input))
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		expected, err := ParseJobs(strings.NewReader(tc.equivalent), ":")
+		if err != nil {
+			t.Fatalf("Got unexpected error %q parsing equivalent config", err)
+		}
+		if !reflect.DeepEqual(expected, jobs) {
+			t.Fatalf("Expected %+v, got %+v", expected, jobs)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		id               string
+		name             string
+		input            string
+		expectedPath     string
+		expectedWarnings []string
+	}{
+		{
+			id:               "Line format",
+			name:             "rotate.conf",
+			input:            "pathglob:/a/*\nrange:1h:30m\n",
+			expectedPath:     "/a/*",
+			expectedWarnings: []string{"Line 2: First range has interval 30m, so the newest files are thinned too; use an interval of 0 or KEEPLAST to keep them all (first-interval)"},
+		},
+		{
+			id:               "JSON",
+			name:             "rotate.JSON",
+			input:            `{"jobs": [{"pathglob": "*.gz", "minkeep": 1, "ranges": [{"age": "1h"}]}]}`,
+			expectedPath:     "*.gz",
+			expectedWarnings: []string{"jobs[0].pathglob: Path glob \"*.gz\" has no directory and will match files in the working directory (bare-glob)"},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		path := filepath.Join(dir, tc.name)
+		if err := ioutil.WriteFile(path, []byte(tc.input), 0644); err != nil {
+			t.Fatalf("Error writing %q: %v", path, err)
+		}
+		jobs, err := ParseFile(path, ":")
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(jobs) != 1 || jobs[0].Files.Pattern != tc.expectedPath {
+			t.Fatalf("Expected one job for %q, got %+v", tc.expectedPath, jobs)
+		}
+		warnings, err := LintFile(path, ":")
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		got := []string{}
+		for _, w := range warnings {
+			got = append(got, w.String())
+		}
+		if !reflect.DeepEqual(tc.expectedWarnings, got) {
+			t.Fatalf("Expected warnings %q, got %q", tc.expectedWarnings, got)
+		}
+	}
+
+	if _, err := ParseFile(filepath.Join(dir, "missing.json"), ":"); err == nil {
+		t.Fatalf("Expected error for a missing file, got nil")
+	}
+}
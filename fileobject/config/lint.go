@@ -0,0 +1,141 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// Names of the checks made by Lint.
+const (
+	// CheckWideInterval flags a range whose Interval, or calendar period, is longer than the range itself, so it keeps at most one file.
+	CheckWideInterval = "wide-interval"
+	// CheckFirstInterval flags a first range with a non-zero Interval, which thins out the newest files.
+	CheckFirstInterval = "first-interval"
+	// CheckBareGlob flags a PATHGLOB with no directory, which matches files in whatever directory filerotate happens to run in.
+	CheckBareGlob = "bare-glob"
+	// CheckBadGlob flags a PATHGLOB that isn't a valid pattern, which makes every run fail.
+	CheckBadGlob = "bad-glob"
+)
+
+// Warning describes a config that's valid but probably not what was meant.
+type Warning struct {
+	// Line is the line the warning applies to, or zero if it applies to the whole job or the config is structured.
+	Line int
+	// Key is the path to the value the warning applies to in a structured config, such as jobs[0].pathglob.
+	Key string
+	// File is the config the warning applies to if it isn't the one that was linted, such as an included config.
+	File string
+	// Job is the name of the job the warning applies to. It's empty for a config without JOB lines.
+	Job string
+	// Check is the name of the check that failed, such as CheckWideInterval.
+	Check string
+	// Message explains the problem.
+	Message string
+}
+
+func (w Warning) String() string {
+	prefix := ""
+	if w.Job != "" {
+		prefix = fmt.Sprintf("Job %q: ", w.Job)
+	}
+	switch {
+	case w.Key != "" && w.File != "":
+		prefix += fmt.Sprintf("%s: %s: ", w.File, w.Key)
+	case w.Key != "":
+		prefix += w.Key + ": "
+	case w.Line != 0 && w.File != "":
+		prefix += fmt.Sprintf("Line %d of %s: ", w.Line, w.File)
+	case w.Line != 0:
+		prefix += fmt.Sprintf("Line %d: ", w.Line)
+	case w.File != "":
+		prefix += w.File + ": "
+	}
+	return fmt.Sprintf("%s%s (%s)", prefix, w.Message, w.Check)
+}
+
+// Lint parses a config like ParseJob and returns warnings about settings that are valid but almost certainly mistakes, ordered by line. The error is only set if the config doesn't parse.
+func Lint(in io.Reader, fieldSep string) ([]Warning, error) {
+	p := newParser(in, fieldSep)
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.sortedWarnings(), nil
+}
+
+// LintFile is like Lint but reads the config at path in the format chosen by its extension, like ParseFile.
+func LintFile(path, fieldSep string) ([]Warning, error) {
+	p, err := parseFile(path, fieldSep)
+	if err != nil {
+		return nil, err
+	}
+	return p.sortedWarnings(), nil
+}
+
+// LintDir is like Lint but reads every config in dir, like ParseDir.
+func LintDir(dir, fieldSep string) ([]Warning, error) {
+	p, err := parseDir(dir, fieldSep)
+	if err != nil {
+		return nil, err
+	}
+	return p.sortedWarnings(), nil
+}
+
+// sortedWarnings returns the warnings for every job, ordered by file and then by line. Warnings about the config that was linted come first.
+func (p *parser) sortedWarnings() []Warning {
+	sort.SliceStable(p.warnings, func(i, j int) bool {
+		if p.warnings[i].File != p.warnings[j].File {
+			return p.warnings[i].File < p.warnings[j].File
+		}
+		return p.warnings[i].Line < p.warnings[j].Line
+	})
+	return p.warnings
+}
+
+// lint checks a parsed job using the line numbers recorded by the parser.
+func (p *parser) lint(job Job) []Warning {
+	warnings := []Warning{}
+	warn := func(pos position, check, format string, a ...interface{}) {
+		warnings = append(warnings, Warning{Line: pos.line, Key: pos.key, File: pos.file, Job: p.name, Check: check, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if !strings.ContainsRune(p.path, filepath.Separator) {
+		warn(p.pathPos, CheckBareGlob, "Path glob %q has no directory and will match files in the working directory", p.path)
+	}
+	if _, err := filepath.Match(p.path, ""); err != nil {
+		warn(p.pathPos, CheckBadGlob, "Path glob %q is invalid: %v", p.path, err)
+	}
+
+	for i, r := range p.ranges {
+		width := r.Age
+		if i > 0 {
+			width -= p.ranges[i-1].Age
+		}
+		spacing, what := r.Interval, fmt.Sprintf("%s interval", agerotate.FormatDuration(r.Interval))
+		if r.Period != agerotate.NoPeriod {
+			spacing, what = r.Period.MaxLength(), r.Period.String()
+		}
+		if spacing > width {
+			warn(p.rangePos[i], CheckWideInterval, "Range covers only %s but keeps one file per %s, so it keeps at most one file", agerotate.FormatDuration(width), what)
+		}
+	}
+	if len(p.ranges) > 0 && p.keepLast == 0 && p.ranges[0].Period == agerotate.NoPeriod && p.ranges[0].Interval > 0 {
+		warn(p.rangePos[0], CheckFirstInterval, "First range has interval %s, so the newest files are thinned too; use an interval of 0 or KEEPLAST to keep them all", agerotate.FormatDuration(p.ranges[0].Interval))
+	}
+
+	return warnings
+}
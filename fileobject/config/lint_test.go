@@ -0,0 +1,161 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		input       string
+		expectedErr string
+		expected    []Warning
+	}{
+		{
+			id:       "Clean",
+			input:    "pathglob:/var/dumps/*.gz\nminkeep:5\nrange:24h:0\nrange:168h:24h\nweekly:1344h\n",
+			expected: []Warning{},
+		},
+		{
+			id:    "Everything wrong",
+			input: "pathglob:*.gz\nrange:6h:1h\nrange:12h:24h\nweekly:150h\n",
+			expected: []Warning{
+				{Line: 1, Check: CheckBareGlob, Message: "Path glob \"*.gz\" has no directory and will match files in the working directory"},
+				{Line: 2, Check: CheckFirstInterval, Message: "First range has interval 1h, so the newest files are thinned too; use an interval of 0 or KEEPLAST to keep them all"},
+				{Line: 3, Check: CheckWideInterval, Message: "Range covers only 6h but keeps one file per 1d interval, so it keeps at most one file"},
+				{Line: 4, Check: CheckWideInterval, Message: "Range covers only 5d18h but keeps one file per week, so it keeps at most one file"},
+			},
+		},
+		{
+			id:       "KEEPLAST covers the newest files",
+			input:    "keeplast:10\npathglob:/var/dumps/*.gz\nminkeep:5\nrange:24h:1h\n",
+			expected: []Warning{},
+		},
+		{
+			id:    "Bad glob",
+			input: "pathglob:/var/dumps/[.gz\nminkeep:5\nrange:24h:0\n",
+			expected: []Warning{
+				{Line: 1, Check: CheckBadGlob, Message: "Path glob \"/var/dumps/[.gz\" is invalid: syntax error in pattern"},
+			},
+		},
+		{
+			id:    "Jobs",
+			input: "job:dumps\npathglob:/var/dumps/*.gz\nminkeep:5\nrange:24h:0\njob:logs\npathglob:/var/log/*.gz\nrange:24h:1h\n",
+			expected: []Warning{
+				{Line: 7, Job: "logs", Check: CheckFirstInterval, Message: "First range has interval 1h, so the newest files are thinned too; use an interval of 0 or KEEPLAST to keep them all"},
+			},
+		},
+		{
+			id:          "Parse error",
+			input:       "pathglob:/var/dumps/*.gz\nrange:24h\n",
+			expectedErr: "Line 2: Range lines must have two values",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		warnings, err := Lint(strings.NewReader(tc.input), ":")
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if len(warnings) != len(tc.expected) {
+			t.Fatalf("Expected warnings %v, got %v", tc.expected, warnings)
+		}
+		for i := range tc.expected {
+			if warnings[i] != tc.expected[i] {
+				t.Fatalf("Expected warning %d to be %v, got %v", i, tc.expected[i], warnings[i])
+			}
+		}
+	}
+}
+
+func TestWarningString(t *testing.T) {
+	for _, tc := range []struct {
+		id       string
+		warning  Warning
+		expected string
+	}{
+		{
+			id:       "Line",
+			warning:  Warning{Line: 3, Check: CheckWideInterval, Message: "Too wide"},
+			expected: "Line 3: Too wide (wide-interval)",
+		},
+		{
+			id:       "Job",
+			warning:  Warning{Line: 3, Job: "dumps", Check: CheckWideInterval, Message: "Too wide"},
+			expected: "Job \"dumps\": Line 3: Too wide (wide-interval)",
+		},
+		{
+			id:       "Included file",
+			warning:  Warning{Line: 3, File: "common.conf", Check: CheckWideInterval, Message: "Too wide"},
+			expected: "Line 3 of common.conf: Too wide (wide-interval)",
+		},
+		{
+			id:       "Key in file",
+			warning:  Warning{Key: "jobs[0].pathglob", File: "a.json", Check: CheckBareGlob, Message: "Bare"},
+			expected: "a.json: jobs[0].pathglob: Bare (bare-glob)",
+		},
+		{
+			id:       "Whole config",
+			warning:  Warning{Check: CheckBadGlob, Message: "Bad"},
+			expected: "Bad (bad-glob)",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		if got := tc.warning.String(); got != tc.expected {
+			t.Fatalf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+}
+
+func TestLintDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	writeConfigs(t, dir, map[string]string{
+		"dumps.conf":         "include:common/limits.conf\npathglob:*.gz\nrange:1h:0\n",
+		"common/limits.conf": "range:30m:10m\n",
+		"logs.conf":          "pathglob:/logs/*\nrange:1h:0\nminkeep:1\n",
+	})
+
+	warnings, err := LintDir(dir, ":")
+	if err != nil {
+		t.Fatalf("Got unexpected error %q", err)
+	}
+	expected := []string{
+		fmt.Sprintf("Job \"dumps\": Line 1 of %s: First range has interval 10m, so the newest files are thinned too; use an interval of 0 or KEEPLAST to keep them all (first-interval)", filepath.Join(dir, "common", "limits.conf")),
+		fmt.Sprintf("Job \"dumps\": Line 2 of %s: Path glob \"*.gz\" has no directory and will match files in the working directory (bare-glob)", filepath.Join(dir, "dumps.conf")),
+	}
+	got := []string{}
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected warnings %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,109 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package fileobject
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// Exclude matches paths that are left out of rotation, using either a glob or a regular expression.
+type Exclude struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+// NewGlobExclude returns an Exclude using a filepath.Match pattern. A pattern without a path separator is matched against the file's name and the name of every directory above it, or only those below the part of the pattern without wildcards when used by a Glob, so "*.partial" leaves out files ending in .partial and "keep" leaves out everything under a directory named keep. A pattern with a separator is matched against the whole path and every directory above it, so "/backup/*/old" leaves out everything under an old directory one level below /backup.
+func NewGlobExclude(pattern string) (*Exclude, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return &Exclude{glob: pattern}, nil
+}
+
+// NewRegexpExclude returns an Exclude using a regular expression, which leaves out any path it matches anywhere, such as `\.partial$`.
+func NewRegexpExclude(expr string) (*Exclude, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Exclude{re: re}, nil
+}
+
+// String returns the pattern or expression.
+func (e *Exclude) String() string {
+	if e.re != nil {
+		return e.re.String()
+	}
+	return e.glob
+}
+
+// Match reports whether path is left out, checking the name of every directory above it.
+func (e *Exclude) Match(path string) bool {
+	return e.MatchBelow(path, "")
+}
+
+// MatchBelow is like Match but a glob only checks path and the directories above it that are below root, so a pattern like "backup*" can't leave out everything because root is /srv/backups. An empty root checks every directory.
+func (e *Exclude) MatchBelow(path, root string) bool {
+	if e.re != nil {
+		return e.re.MatchString(path)
+	}
+	if root != "" {
+		root = filepath.Clean(root)
+	}
+	whole := strings.ContainsRune(e.glob, filepath.Separator)
+	for p := filepath.Clean(path); p != root; {
+		target := p
+		if !whole {
+			target = filepath.Base(p)
+		}
+		if ok, _ := filepath.Match(e.glob, target); ok {
+			return true
+		}
+		parent := filepath.Dir(p)
+		if parent == p || parent == "." {
+			return false
+		}
+		p = parent
+	}
+	return false
+}
+
+// Excluded returns the first of excludes that matches path, or nil if none do.
+func Excluded(excludes []*Exclude, path string) *Exclude {
+	for _, e := range excludes {
+		if e.Match(path) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Excluded returns the first of the Glob's excludes that matches path, or nil if none do. Globs are matched with MatchBelow so that only the directories below the part of Pattern without wildcards are checked.
+func (g Glob) Excluded(path string) *Exclude {
+	root, _ := splitPattern(g.Pattern)
+	for _, e := range g.Exclude {
+		if e.MatchBelow(path, root) {
+			return e
+		}
+	}
+	return nil
+}
+
+// ExcludeFunc returns a function for agerotate.Filter that leaves out objects whose IDs, taken to be paths, any of excludes match.
+func ExcludeFunc(excludes ...*Exclude) func(agerotate.Object) bool {
+	return func(o agerotate.Object) bool {
+		return Excluded(excludes, o.ID()) != nil
+	}
+}
@@ -0,0 +1,154 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package fileobject
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+func TestExcludeMatch(t *testing.T) {
+	for _, tc := range []struct {
+		id       string
+		glob     string
+		regexp   string
+		path     string
+		root     string
+		expected bool
+	}{
+		{id: "Name glob", glob: "*.partial", path: "/backup/db.bz2.partial", expected: true},
+		{id: "Name glob miss", glob: "*.partial", path: "/backup/db.bz2", expected: false},
+		{id: "Directory name", glob: "keep", path: "/backup/keep/db.bz2", expected: true},
+		{id: "Directory name deeper", glob: "keep", path: "/backup/keep/2026/db.bz2", expected: true},
+		{id: "Directory name miss", glob: "keep", path: "/backup/keeper/db.bz2", expected: false},
+		{id: "Relative path", glob: "keep", path: "keep/db.bz2", expected: true},
+		{id: "Whole path", glob: "/backup/*/old", path: "/backup/db/old/db.bz2", expected: true},
+		{id: "Whole path file", glob: "/backup/*.tmp", path: "/backup/db.tmp", expected: true},
+		{id: "Whole path miss", glob: "/backup/*/old", path: "/backup/old/db.bz2", expected: false},
+		{id: "Below root", glob: "keep", path: "/backup/keep/db.bz2", root: "/backup", expected: true},
+		{id: "Root name", glob: "backup*", path: "/srv/backups/db.bz2", root: "/srv/backups", expected: false},
+		{id: "Above root", glob: "tmp*", path: "/var/tmp/dumps/db.bz2", root: "/var/tmp/dumps", expected: false},
+		{id: "Above root without root", glob: "tmp*", path: "/var/tmp/dumps/db.bz2", expected: true},
+		{id: "Whole path of root", glob: "/var/*", path: "/var/tmp/db.bz2", root: "/var/tmp", expected: false},
+		{id: "Filesystem root", glob: "srv", path: "/srv/db.bz2", root: "/", expected: true},
+		{id: "Relative root", glob: "keep", path: "keep/db.bz2", root: ".", expected: true},
+		{id: "Regexp", regexp: `\.partial$`, path: "/backup/db.bz2.partial", expected: true},
+		{id: "Regexp anywhere", regexp: `/keep/`, path: "/backup/keep/db.bz2", expected: true},
+		{id: "Regexp miss", regexp: `\.partial$`, path: "/backup/db.partial.bz2", expected: false},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		var e *Exclude
+		var err error
+		if tc.regexp != "" {
+			e, err = NewRegexpExclude(tc.regexp)
+		} else {
+			e, err = NewGlobExclude(tc.glob)
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if got := e.MatchBelow(filepath.FromSlash(tc.path), filepath.FromSlash(tc.root)); got != tc.expected {
+			t.Fatalf("Expected %v for %q, got %v", tc.expected, tc.path, got)
+		}
+	}
+
+	if _, err := NewGlobExclude("[a-"); err == nil {
+		t.Fatalf("Expected error for a bad glob, got nil")
+	}
+	if _, err := NewRegexpExclude("(a"); err == nil {
+		t.Fatalf("Expected error for a bad regexp, got nil")
+	}
+}
+
+func TestGlobExclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agerotate")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.bz2", "b.bz2.partial", "keep/c.bz2", "other/d.bz2"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		if err := ioutil.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+	}
+	partial, err := NewGlobExclude("*.partial")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	keep, err := NewRegexpExclude(`/keep/`)
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+
+	objects, err := Glob{Pattern: filepath.Join(dir, "*"), Exclude: []*Exclude{partial}}.List()
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	// The directories match the glob too.
+	expected := []string{"a.bz2", "keep", "other"}
+	if got := baseNames(objects); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+
+	objects, err = Glob{Pattern: filepath.Join(dir, "*", "*.bz2"), Exclude: []*Exclude{keep}}.List()
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	expected = []string{"d.bz2"}
+	if got := baseNames(objects); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+
+	// The temporary directory is named agerotate followed by digits, which mustn't leave out everything below it.
+	tempName, err := NewGlobExclude("agerotate*")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	objects, err = Glob{Pattern: filepath.Join(dir, "*", "*.bz2"), Exclude: []*Exclude{tempName}}.List()
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	expected = []string{"c.bz2", "d.bz2"}
+	if got := baseNames(objects); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestExcludeFunc(t *testing.T) {
+	partial, err := NewGlobExclude("*.partial")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	exclude := ExcludeFunc(partial)
+	if !exclude(File{path: "/backup/db.partial"}) || exclude(File{path: "/backup/db.bz2"}) {
+		t.Fatalf("Expected only the .partial file to be excluded")
+	}
+}
+
+// baseNames returns the sorted base names of objects.
+func baseNames(objects []agerotate.Object) []string {
+	names := []string{}
+	for _, o := range objects {
+		names = append(names, filepath.Base(o.ID()))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,145 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package fileobject
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NameTime extracts timestamps from file names. The expression is matched against the base name of each file and the timestamp is built from its named groups: year, month, day, hour, minute, and second, or unix for seconds since the Unix epoch. Month may be a number or an English month name or abbreviation. Missing fields default to the start of the day, month, or year, but year is required unless unix is present.
+type NameTime struct {
+	re       *regexp.Regexp
+	location *time.Location
+}
+
+// nameGroups are the named groups NameTime understands.
+var nameGroups = map[string]bool{
+	"year":   true,
+	"month":  true,
+	"day":    true,
+	"hour":   true,
+	"minute": true,
+	"second": true,
+	"unix":   true,
+}
+
+// strftimeVerbs maps the strftime conversions accepted by NewStrftimeNameTime to the regular expressions they become.
+var strftimeVerbs = map[byte]string{
+	'Y': `(?P<year>\d{4})`,
+	'm': `(?P<month>\d{2})`,
+	'b': `(?P<month>[A-Za-z]{3})`,
+	'd': `(?P<day>\d{2})`,
+	'H': `(?P<hour>\d{2})`,
+	'M': `(?P<minute>\d{2})`,
+	'S': `(?P<second>\d{2})`,
+	's': `(?P<unix>\d+)`,
+	'%': `%`,
+}
+
+// NewRegexpNameTime returns a NameTime using the regular expression expr. Times without a time zone are interpreted in location, or time.Local if location is nil.
+func NewRegexpNameTime(expr string, location *time.Location) (*NameTime, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]bool{}
+	for _, name := range re.SubexpNames()[1:] {
+		if name == "" {
+			continue
+		}
+		if !nameGroups[name] {
+			return nil, fmt.Errorf("Unknown group %q in %q", name, expr)
+		}
+		found[name] = true
+	}
+	if !found["year"] && !found["unix"] {
+		return nil, fmt.Errorf("Expression %q needs a year or unix group", expr)
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return &NameTime{re: re, location: location}, nil
+}
+
+// NewStrftimeNameTime returns a NameTime using a strftime-style layout such as "backup-%Y%m%d-%H%M.tar.gz". The conversions %Y, %m, %b, %d, %H, %M, %S, %s, and %% are supported and all other text must match literally. The layout can match anywhere in the name.
+func NewStrftimeNameTime(layout string, location *time.Location) (*NameTime, error) {
+	expr := strings.Builder{}
+	literal := strings.Builder{}
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			literal.WriteByte(layout[i])
+			continue
+		}
+		if i+1 == len(layout) {
+			return nil, fmt.Errorf("Layout %q ends with %%", layout)
+		}
+		i++
+		verb, ok := strftimeVerbs[layout[i]]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported conversion %%%c in %q", layout[i], layout)
+		}
+		expr.WriteString(regexp.QuoteMeta(literal.String()))
+		literal.Reset()
+		expr.WriteString(verb)
+	}
+	expr.WriteString(regexp.QuoteMeta(literal.String()))
+	return NewRegexpNameTime(expr.String(), location)
+}
+
+// String returns the regular expression used to match names.
+func (n *NameTime) String() string {
+	return n.re.String()
+}
+
+// Time returns the timestamp in name. It returns false if name doesn't match or the fields don't form a valid time.
+func (n *NameTime) Time(name string) (time.Time, bool) {
+	match := n.re.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+	fields := map[string]int{"month": 1, "day": 1}
+	for i, group := range n.re.SubexpNames() {
+		if group == "" || match[i] == "" {
+			continue
+		}
+		value, err := strconv.Atoi(match[i])
+		if err != nil && group == "month" {
+			value, err = parseMonth(match[i])
+		}
+		if err != nil {
+			return time.Time{}, false
+		}
+		fields[group] = value
+	}
+	if unix, ok := fields["unix"]; ok {
+		return time.Unix(int64(unix), 0), true
+	}
+	t := time.Date(fields["year"], time.Month(fields["month"]), fields["day"], fields["hour"], fields["minute"], fields["second"], 0, n.location)
+	if t.Month() != time.Month(fields["month"]) || t.Day() != fields["day"] || t.Hour() != fields["hour"] || t.Minute() != fields["minute"] || t.Second() != fields["second"] {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// parseMonth parses an English month name or its three letter abbreviation.
+func parseMonth(s string) (int, error) {
+	s = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+	for _, layout := range []string{"Jan", "January"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return int(t.Month()), nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid month %q", s)
+}
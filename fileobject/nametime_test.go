@@ -0,0 +1,206 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package fileobject
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNameTime(t *testing.T) {
+	for _, tc := range []struct {
+		id          string
+		layout      string
+		regex       string
+		name        string
+		expectedErr string
+		expected    time.Time
+		expectedOK  bool
+	}{
+		{
+			id:         "Strftime date and time",
+			layout:     "db-%Y%m%d-%H%M%S.sql.gz",
+			name:       "db-20260314-015926.sql.gz",
+			expected:   time.Date(2026, 3, 14, 1, 59, 26, 0, time.UTC),
+			expectedOK: true,
+		},
+		{
+			id:         "Strftime date only",
+			layout:     "%Y-%m-%d",
+			name:       "backup.2025-12-31.tar",
+			expected:   time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			expectedOK: true,
+		},
+		{
+			id:         "Strftime month name",
+			layout:     "%d%b%Y",
+			name:       "14MAR2026",
+			expected:   time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC),
+			expectedOK: true,
+		},
+		{
+			id:         "Strftime literal metacharacters",
+			layout:     "a.b+%Y",
+			name:       "axb+2026",
+			expectedOK: false,
+		},
+		{
+			id:         "Strftime unix",
+			layout:     "snap-%s",
+			name:       "snap-1700000000",
+			expected:   time.Unix(1700000000, 0),
+			expectedOK: true,
+		},
+		{
+			id:         "Strftime invalid date",
+			layout:     "%Y%m%d",
+			name:       "20260231",
+			expectedOK: false,
+		},
+		{
+			id:         "Regex named groups",
+			regex:      `^(?P<day>\d+)\.(?P<month>\d+)\.(?P<year>\d+)_(?P<hour>\d+)h`,
+			name:       "7.4.2026_13h.log",
+			expected:   time.Date(2026, 4, 7, 13, 0, 0, 0, time.UTC),
+			expectedOK: true,
+		},
+		{
+			id:         "Regex no match",
+			regex:      `^(?P<year>\d{4})`,
+			name:       "latest.log",
+			expectedOK: false,
+		},
+		{
+			id:          "Unsupported conversion",
+			layout:      "%Y%q",
+			expectedErr: "Unsupported conversion %q in \"%Y%q\"",
+		},
+		{
+			id:          "Trailing percent",
+			layout:      "%Y%",
+			expectedErr: "Layout \"%Y%\" ends with %",
+		},
+		{
+			id:          "Unknown group",
+			regex:       `(?P<year>\d+)(?P<week>\d+)`,
+			expectedErr: "Unknown group \"week\" in \"(?P<year>\\\\d+)(?P<week>\\\\d+)\"",
+		},
+		{
+			id:          "No year",
+			regex:       `(?P<month>\d+)`,
+			expectedErr: "Expression \"(?P<month>\\\\d+)\" needs a year or unix group",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		var nt *NameTime
+		var err error
+		if tc.regex != "" {
+			nt, err = NewRegexpNameTime(tc.regex, time.UTC)
+		} else {
+			nt, err = NewStrftimeNameTime(tc.layout, time.UTC)
+		}
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if err.Error() != tc.expectedErr {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		got, ok := nt.Time(tc.name)
+		if ok != tc.expectedOK {
+			t.Fatalf("Expected match %v, got %v", tc.expectedOK, ok)
+		}
+		if ok && !got.Equal(tc.expected) {
+			t.Fatalf("Expected time %v, got %v", tc.expected, got)
+		}
+	}
+}
+
+func TestGlobNameTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agerotate")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	defer os.RemoveAll(dir)
+	stamp := time.Now().Add(-48 * time.Hour).UTC()
+	dated := filepath.Join(dir, "dump-"+stamp.Format("20060102-150405"))
+	undated := filepath.Join(dir, "dump-latest")
+	for _, path := range []string{dated, undated} {
+		if err := ioutil.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+	}
+	nt, err := NewStrftimeNameTime("dump-%Y%m%d-%H%M%S", time.UTC)
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+
+	for _, tc := range []struct {
+		id          string
+		unmatched   Unmatched
+		expectedIDs []string
+		expectedErr string
+	}{
+		{
+			id:          "Skip",
+			unmatched:   UnmatchedSkip,
+			expectedIDs: []string{dated},
+		},
+		{
+			id:          "MTime",
+			unmatched:   UnmatchedMTime,
+			expectedIDs: []string{dated, undated},
+		},
+		{
+			id:          "Error",
+			unmatched:   UnmatchedError,
+			expectedErr: "No timestamp matching \"dump-(?P<year>\\\\d{4})(?P<month>\\\\d{2})(?P<day>\\\\d{2})-(?P<hour>\\\\d{2})(?P<minute>\\\\d{2})(?P<second>\\\\d{2})\" in \"" + undated + "\"",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		objects, err := Glob{Pattern: filepath.Join(dir, "dump-*"), NameTime: nt, Unmatched: tc.unmatched}.List()
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if err.Error() != tc.expectedErr {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		if len(objects) != len(tc.expectedIDs) {
+			t.Fatalf("Expected %d objects, got %d", len(tc.expectedIDs), len(objects))
+		}
+		for i, o := range objects {
+			if o.ID() != tc.expectedIDs[i] {
+				t.Fatalf("Expected object %d to be %q, got %q", i, tc.expectedIDs[i], o.ID())
+			}
+			age := o.Age()
+			if o.ID() == dated && (age < 48*time.Hour || age > 49*time.Hour) {
+				t.Fatalf("Expected %q to be about 48h old, got %v", o.ID(), age)
+			}
+			if o.ID() == undated && age > time.Hour {
+				t.Fatalf("Expected %q to use its mtime, got %v", o.ID(), age)
+			}
+		}
+	}
+}
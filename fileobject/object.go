@@ -12,6 +12,8 @@ THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLI
 package fileobject
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -19,23 +21,52 @@ import (
 	"github.com/AgentZombie/agerotate"
 )
 
-// File captures a file path and it's mtime, providing methods for the Object interface. The mtime is cached to avoid hammering the filesystem during sorting.
+// File captures a file path, it's mtime, and it's size, providing methods for the Object and Sizer interfaces. The mtime is cached to avoid hammering the filesystem during sorting.
 type File struct {
 	path string
 	age  time.Duration
+	size int64
+	// pruneRoot, if set, is the directory above which Delete stops removing directories left empty.
+	pruneRoot string
 }
 
-func newFile(path string) (File, error) {
+// newFile returns the File at path with its age measured from now.
+func newFile(path string, now time.Time) (File, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
 		return File{}, err
 	}
 	return File{
 		path: path,
-		age:  time.Now().Sub(fi.ModTime()),
+		age:  now.Sub(fi.ModTime()),
+		size: fi.Size(),
 	}, nil
 }
 
+// Unmatched is what Glob does with a file whose name doesn't contain a timestamp.
+type Unmatched int
+
+const (
+	// UnmatchedSkip leaves the file out of the listing so it's never deleted.
+	UnmatchedSkip Unmatched = iota
+	// UnmatchedMTime falls back to the file's mtime.
+	UnmatchedMTime
+	// UnmatchedError fails the listing.
+	UnmatchedError
+)
+
+func (u Unmatched) String() string {
+	switch u {
+	case UnmatchedSkip:
+		return "skip"
+	case UnmatchedMTime:
+		return "mtime"
+	case UnmatchedError:
+		return "error"
+	}
+	return fmt.Sprintf("Unmatched(%d)", int(u))
+}
+
 // ID returns the path for the file object.
 func (f File) ID() string {
 	return f.path
@@ -46,16 +77,24 @@ func (f File) Age() time.Duration {
 	return f.age
 }
 
-// Delete attempts to remove the file object. No error is returned if it already doesn't exist.
+// Size returns the size of the file in bytes as of when it was listed.
+func (f File) Size() int64 {
+	return f.size
+}
+
+// Delete attempts to remove the file object. No error is returned if it already doesn't exist. If the file was listed by a Glob with RemoveEmptyDirs set, directories left empty are removed too.
 func (f File) Delete() error {
 	err := os.Remove(f.path)
 	if os.IsNotExist(err) {
-		return nil
+		err = nil
+	}
+	if err == nil && f.pruneRoot != "" {
+		removeEmptyDirs(f.path, f.pruneRoot)
 	}
 	return err
 }
 
-// Files wraps a string (assumed to be a path glob) to provide Objects operations on it.
+// Files wraps a string (assumed to be a path glob) to provide Objects operations on it. The glob may use ** to match any number of directories, as with Glob. Ages are measured from time.Now; use Glob to measure them from another moment or to set walking options.
 type Files string
 
 // ID returns the path glob for the object.
@@ -65,19 +104,92 @@ func (f Files) ID() string {
 
 // List returns the File items matching the glob.
 func (f Files) List() ([]agerotate.Object, error) {
-	paths, err := filepath.Glob(string(f))
+	return f.ListContext(context.Background())
+}
+
+// ListContext returns the File items matching the glob, giving up if ctx is done before every file has been examined.
+func (f Files) ListContext(ctx context.Context) ([]agerotate.Object, error) {
+	return Glob{Pattern: string(f)}.ListContext(ctx)
+}
+
+// Glob provides Objects operations on the files matching a path glob. Unlike Files it can take the age of each file from a timestamp in its name instead of its mtime, which survives copies and restores that reset mtime.
+type Glob struct {
+	// Pattern is the path glob, as used by filepath.Glob. A ** element matches any number of directories, including none, such as /backups/**/*.tar.gz. Patterns with ** match files but not directories and are found by walking the directories below the part of the pattern without wildcards.
+	Pattern string
+	// FollowSymlinks makes ** patterns descend into symlinked directories. Each directory is walked only once, so symlink loops are harmless.
+	FollowSymlinks bool
+	// MaxDepth, if positive, limits how many directory levels below the part of the pattern without wildcards a ** pattern descends.
+	MaxDepth int
+	// RemoveEmptyDirs makes deleting a file also remove the directories above it that are left empty, up to the part of the pattern without wildcards.
+	RemoveEmptyDirs bool
+	// NameTime, if set, is used to find each file's timestamp in its base name.
+	NameTime *NameTime
+	// Unmatched is what to do with files whose names NameTime doesn't match.
+	Unmatched Unmatched
+	// Exclude lists files to leave out even though they match Pattern. They're never listed, so they're never considered or deleted. Globs are only checked against directories below the part of Pattern without wildcards, see Excluded.
+	Exclude []*Exclude
+	// Now is the moment ages are measured from. The zero value means time.Now. Files newer than Now have negative ages.
+	Now time.Time
+}
+
+// ID returns the path glob for the object.
+func (g Glob) ID() string {
+	return g.Pattern
+}
+
+// List returns the File items matching the glob.
+func (g Glob) List() ([]agerotate.Object, error) {
+	return g.ListContext(context.Background())
+}
+
+// ListContext returns the File items matching the glob, giving up if ctx is done before every file has been examined.
+func (g Glob) ListContext(ctx context.Context) ([]agerotate.Object, error) {
+	var paths []string
+	var err error
+	if IsRecursive(g.Pattern) {
+		paths, err = g.walk(ctx)
+	} else {
+		paths, err = filepath.Glob(g.Pattern)
+	}
 	if err != nil {
 		return nil, err
 	}
+	pruneRoot := ""
+	if g.RemoveEmptyDirs {
+		pruneRoot, _ = splitPattern(g.Pattern)
+	}
+	now := g.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
 	fObjs := []agerotate.Object{}
 	for _, path := range paths {
-		nf, err := newFile(path)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if g.Excluded(path) != nil {
+			continue
+		}
+		nf, err := newFile(path, now)
 		if err != nil {
 			if os.IsNotExist(err) {
 				continue
 			}
 			return nil, err
 		}
+		nf.pruneRoot = pruneRoot
+		if g.NameTime != nil {
+			if t, ok := g.NameTime.Time(filepath.Base(path)); ok {
+				nf.age = now.Sub(t)
+			} else {
+				switch g.Unmatched {
+				case UnmatchedSkip:
+					continue
+				case UnmatchedError:
+					return nil, fmt.Errorf("No timestamp matching %q in %q", g.NameTime, path)
+				}
+			}
+		}
 		fObjs = append(fObjs, nf)
 	}
 	return fObjs, nil
@@ -0,0 +1,64 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package fileobject
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGlobNow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agerotate")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	defer os.RemoveAll(dir)
+	mtime := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+
+	for _, tc := range []struct {
+		id          string
+		now         time.Time
+		expectedAge time.Duration
+	}{
+		{
+			id:          "Later",
+			now:         mtime.Add(30 * 24 * time.Hour),
+			expectedAge: 30 * 24 * time.Hour,
+		},
+		{
+			id:          "Before the file existed",
+			now:         mtime.Add(-time.Hour),
+			expectedAge: -time.Hour,
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		objects, err := Glob{Pattern: filepath.Join(dir, "*"), Now: tc.now}.List()
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		if len(objects) != 1 {
+			t.Fatalf("Expected 1 object, got %d", len(objects))
+		}
+		if age := objects[0].Age(); age != tc.expectedAge {
+			t.Fatalf("Expected age %v, got %v", tc.expectedAge, age)
+		}
+	}
+}
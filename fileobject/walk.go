@@ -0,0 +1,192 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package fileobject
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Recursive is the pattern element that matches any number of directories, including none.
+const Recursive = "**"
+
+// IsRecursive reports whether pattern has a ** element, so that a Glob walks the directory tree rather than using filepath.Glob.
+func IsRecursive(pattern string) bool {
+	for _, elem := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if elem == Recursive {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPattern splits pattern into its root, the leading directories without wildcards, and the elements after it.
+func splitPattern(pattern string) (string, []string) {
+	elems := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+	i := 0
+	for i < len(elems)-1 && !strings.ContainsAny(elems[i], "*?[\\") {
+		i++
+	}
+	root := filepath.FromSlash(strings.Join(elems[:i], "/"))
+	switch {
+	case i == 1 && elems[0] == "":
+		root = string(filepath.Separator)
+	case root == "":
+		root = "."
+	}
+	return root, elems[i:]
+}
+
+// walker finds the files matching a ** pattern by walking the directories below its root, descending only into directories that can still lead to a match.
+type walker struct {
+	ctx            context.Context
+	elems          []string
+	followSymlinks bool
+	maxDepth       int
+	// visited holds the directories walked so far, resolved through symlinks, so a symlink loop is only walked once.
+	visited map[string]bool
+	paths   []string
+}
+
+// walk returns the files matching the ** pattern in g, in lexical order within each directory.
+func (g Glob) walk(ctx context.Context) ([]string, error) {
+	root, elems := splitPattern(g.Pattern)
+	w := walker{
+		ctx:            ctx,
+		elems:          elems,
+		followSymlinks: g.FollowSymlinks,
+		maxDepth:       g.MaxDepth,
+		visited:        map[string]bool{},
+	}
+	if fi, err := os.Stat(root); err != nil || !fi.IsDir() {
+		return nil, nil
+	}
+	if err := w.dir(root, 0, w.closure([]int{0})); err != nil {
+		return nil, err
+	}
+	return w.paths, nil
+}
+
+// closure adds to states the states reachable by letting each ** match no directories.
+func (w *walker) closure(states []int) []int {
+	seen := map[int]bool{}
+	out := []int{}
+	for len(states) > 0 {
+		s := states[0]
+		states = states[1:]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+		if s < len(w.elems) && w.elems[s] == Recursive {
+			states = append(states, s+1)
+		}
+	}
+	return out
+}
+
+// next returns the states after matching name in each of states. A ** stays put since it may match further directories.
+func (w *walker) next(states []int, name string) []int {
+	out := []int{}
+	for _, s := range states {
+		if s == len(w.elems) {
+			continue
+		}
+		if w.elems[s] == Recursive {
+			out = append(out, s)
+		} else if ok, _ := filepath.Match(w.elems[s], name); ok {
+			out = append(out, s+1)
+		}
+	}
+	return w.closure(out)
+}
+
+// dir walks the directory at path, which is depth levels below the root, with the pattern states reached at it.
+func (w *walker) dir(path string, depth int, states []int) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if w.visited[resolved] {
+		return nil
+	}
+	w.visited[resolved] = true
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		next := w.next(states, entry.Name())
+		if len(next) == 0 {
+			continue
+		}
+		child := filepath.Join(path, entry.Name())
+		isDir := entry.IsDir()
+		if entry.Mode()&os.ModeSymlink != 0 {
+			fi, err := os.Stat(child)
+			if err != nil {
+				// A dangling symlink is left alone.
+				continue
+			}
+			if fi.IsDir() && !w.followSymlinks {
+				continue
+			}
+			isDir = fi.IsDir()
+		}
+		if !isDir {
+			for _, s := range next {
+				if s == len(w.elems) {
+					w.paths = append(w.paths, child)
+					break
+				}
+			}
+			continue
+		}
+		if w.maxDepth > 0 && depth+1 > w.maxDepth {
+			continue
+		}
+		if err := w.dir(child, depth+1, next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeEmptyDirs removes the directories above path up to but not including root for as long as they're empty. Removal stops at the first directory that can't be removed, which is usually because it isn't empty, and at the first symlink, since removing one would unlink it whatever its target holds.
+func removeEmptyDirs(path, root string) {
+	root = filepath.Clean(root)
+	prefix := root + string(filepath.Separator)
+	if root == "." {
+		prefix = ""
+	} else if strings.HasSuffix(root, string(filepath.Separator)) {
+		prefix = root
+	}
+	for dir := filepath.Dir(path); dir != root && dir != "." && strings.HasPrefix(dir, prefix); dir = filepath.Dir(dir) {
+		fi, err := os.Lstat(dir)
+		if err != nil || !fi.IsDir() {
+			return
+		}
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
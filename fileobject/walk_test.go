@@ -0,0 +1,240 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package fileobject
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPattern(t *testing.T) {
+	for _, tc := range []struct {
+		id            string
+		pattern       string
+		expectedRoot  string
+		expectedElems []string
+	}{
+		{id: "Absolute", pattern: "/backups/**/*.tar.gz", expectedRoot: "/backups", expectedElems: []string{"**", "*.tar.gz"}},
+		{id: "Wildcard in the middle", pattern: "/backups/20*/**/db.gz", expectedRoot: "/backups", expectedElems: []string{"20*", "**", "db.gz"}},
+		{id: "From the top", pattern: "/**/db.gz", expectedRoot: "/", expectedElems: []string{"**", "db.gz"}},
+		{id: "Relative", pattern: "**/*.gz", expectedRoot: ".", expectedElems: []string{"**", "*.gz"}},
+		{id: "Relative with directory", pattern: "dumps/**", expectedRoot: "dumps", expectedElems: []string{"**"}},
+		{id: "Plain glob", pattern: "/backups/*.gz", expectedRoot: "/backups", expectedElems: []string{"*.gz"}},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		root, elems := splitPattern(filepath.FromSlash(tc.pattern))
+		if root != filepath.FromSlash(tc.expectedRoot) || !reflect.DeepEqual(elems, tc.expectedElems) {
+			t.Fatalf("Expected %q and %q, got %q and %q", tc.expectedRoot, tc.expectedElems, root, elems)
+		}
+	}
+}
+
+// makeTree creates each of files below dir, along with any directories needed.
+func makeTree(t *testing.T, dir string, files []string) {
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		if err := ioutil.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+	}
+}
+
+// relPaths returns the IDs of objects relative to dir, with forward slashes.
+func relPaths(t *testing.T, dir string, ids []string) []string {
+	rel := []string{}
+	for _, id := range ids {
+		r, err := filepath.Rel(dir, id)
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	return rel
+}
+
+func TestGlobRecursive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agerotate")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	defer os.RemoveAll(dir)
+	makeTree(t, dir, []string{
+		"backups/top.tar.gz",
+		"backups/2026/09/a.tar.gz",
+		"backups/2026/10/b.tar.gz",
+		"backups/2026/10/b.log",
+		"backups/2026/10/deep/c.tar.gz",
+		"elsewhere/2025/12/d.tar.gz",
+	})
+	if err := os.Symlink(filepath.Join(dir, "elsewhere", "2025"), filepath.Join(dir, "backups", "2025")); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	// A loop back up the tree.
+	if err := os.Symlink(filepath.Join(dir, "backups"), filepath.Join(dir, "backups", "2026", "loop")); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	backups := filepath.Join(dir, "backups")
+
+	for _, tc := range []struct {
+		id             string
+		pattern        string
+		followSymlinks bool
+		maxDepth       int
+		expected       []string
+	}{
+		{
+			id:       "Any depth",
+			pattern:  "**/*.tar.gz",
+			expected: []string{"2026/09/a.tar.gz", "2026/10/b.tar.gz", "2026/10/deep/c.tar.gz", "top.tar.gz"},
+		},
+		{
+			id:       "Dated layout",
+			pattern:  "2026/**/b.*",
+			expected: []string{"2026/10/b.log", "2026/10/b.tar.gz"},
+		},
+		{
+			id:       "Wildcard directory before **",
+			pattern:  "20*/*/**/*.tar.gz",
+			expected: []string{"2026/09/a.tar.gz", "2026/10/b.tar.gz", "2026/10/deep/c.tar.gz"},
+		},
+		{
+			id:       "Everything below",
+			pattern:  "2026/10/**",
+			expected: []string{"2026/10/b.log", "2026/10/b.tar.gz", "2026/10/deep/c.tar.gz"},
+		},
+		{
+			id:       "Depth limit",
+			pattern:  "**/*.tar.gz",
+			maxDepth: 2,
+			expected: []string{"2026/09/a.tar.gz", "2026/10/b.tar.gz", "top.tar.gz"},
+		},
+		{
+			id:             "Following symlinks",
+			pattern:        "**/*.tar.gz",
+			followSymlinks: true,
+			expected:       []string{"2025/12/d.tar.gz", "2026/09/a.tar.gz", "2026/10/b.tar.gz", "2026/10/deep/c.tar.gz", "top.tar.gz"},
+		},
+		{
+			id:       "No matches",
+			pattern:  "**/*.zip",
+			expected: []string{},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		g := Glob{Pattern: filepath.Join(backups, filepath.FromSlash(tc.pattern)), FollowSymlinks: tc.followSymlinks, MaxDepth: tc.maxDepth}
+		objects, err := g.List()
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		ids := []string{}
+		for _, o := range objects {
+			ids = append(ids, o.ID())
+		}
+		if got := relPaths(t, backups, ids); !reflect.DeepEqual(tc.expected, got) {
+			t.Fatalf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+
+	objects, err := Glob{Pattern: filepath.Join(dir, "missing", "**")}.List()
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("Expected no objects and no error for a missing root, got %d and %v", len(objects), err)
+	}
+}
+
+func TestRemoveEmptyDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agerotate")
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	defer os.RemoveAll(dir)
+	backups := filepath.Join(dir, "backups")
+
+	for _, tc := range []struct {
+		id              string
+		removeEmptyDirs bool
+		followSymlinks  bool
+		deleted         []string
+		expectedGone    []string
+		expectedKept    []string
+	}{
+		{
+			id:           "Disabled",
+			deleted:      []string{"2026/09/a.tar.gz"},
+			expectedKept: []string{"2026/09", "2026/10"},
+		},
+		{
+			id:              "Only empty directories",
+			removeEmptyDirs: true,
+			deleted:         []string{"2026/10/b.tar.gz"},
+			expectedKept:    []string{"2026/09", "2026/10"},
+		},
+		{
+			id:              "Up to the root",
+			removeEmptyDirs: true,
+			deleted:         []string{"2026/09/a.tar.gz", "2026/10/b.tar.gz", "2026/10/c.tar.gz"},
+			expectedGone:    []string{"2026/09", "2026/10", "2026"},
+			expectedKept:    []string{""},
+		},
+		{
+			id:              "Symlinked directory",
+			removeEmptyDirs: true,
+			followSymlinks:  true,
+			deleted:         []string{"link/d.tar.gz"},
+			expectedKept:    []string{"link", "link/keep.txt"},
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		for _, name := range []string{"backups", "elsewhere"} {
+			if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+				t.Fatalf("Unexpected err: %q", err)
+			}
+		}
+		makeTree(t, dir, []string{
+			"backups/2026/09/a.tar.gz",
+			"backups/2026/10/b.tar.gz",
+			"backups/2026/10/c.tar.gz",
+			"elsewhere/d.tar.gz",
+			"elsewhere/keep.txt",
+		})
+		if err := os.Symlink(filepath.Join(dir, "elsewhere"), filepath.Join(backups, "link")); err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		objects, err := Glob{Pattern: filepath.Join(backups, "**", "*.tar.gz"), FollowSymlinks: tc.followSymlinks, RemoveEmptyDirs: tc.removeEmptyDirs}.List()
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		for _, o := range objects {
+			for _, name := range tc.deleted {
+				if o.ID() == filepath.Join(backups, filepath.FromSlash(name)) {
+					if err := o.Delete(); err != nil {
+						t.Fatalf("Unexpected err: %q", err)
+					}
+				}
+			}
+		}
+		for _, name := range tc.expectedGone {
+			if _, err := os.Stat(filepath.Join(backups, filepath.FromSlash(name))); !os.IsNotExist(err) {
+				t.Fatalf("Expected %q to be removed, got %v", name, err)
+			}
+		}
+		for _, name := range tc.expectedKept {
+			if _, err := os.Stat(filepath.Join(backups, filepath.FromSlash(name))); err != nil {
+				t.Fatalf("Expected %q to remain, got %v", name, err)
+			}
+		}
+	}
+}
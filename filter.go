@@ -0,0 +1,53 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package agerotate
+
+import (
+	"context"
+)
+
+// Filter wraps Objects, leaving out every object Exclude reports. Excluded objects are never listed, so nothing using the Filter can consider or delete them.
+type Filter struct {
+	Objects Objects
+	// Exclude reports whether an object is to be left out. A nil Exclude leaves nothing out.
+	Exclude func(Object) bool
+}
+
+// ID returns the ID of the wrapped Objects.
+func (f Filter) ID() string {
+	return f.Objects.ID()
+}
+
+// List returns the wrapped Objects' objects that aren't excluded.
+func (f Filter) List() ([]Object, error) {
+	return f.ListContext(context.Background())
+}
+
+// ListContext returns the wrapped Objects' objects that aren't excluded. The wrapped Objects' ListContext is used if it implements ContextLister.
+func (f Filter) ListContext(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	var err error
+	if lister, ok := f.Objects.(ContextLister); ok {
+		objects, err = lister.ListContext(ctx)
+	} else {
+		objects, err = f.Objects.List()
+	}
+	if err != nil || f.Exclude == nil {
+		return objects, err
+	}
+	kept := make([]Object, 0, len(objects))
+	for _, o := range objects {
+		if !f.Exclude(o) {
+			kept = append(kept, o)
+		}
+	}
+	return kept, nil
+}
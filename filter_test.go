@@ -0,0 +1,105 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package agerotate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type filterObject string
+
+func (o filterObject) Age() time.Duration { return time.Hour }
+func (o filterObject) Delete() error      { return nil }
+func (o filterObject) ID() string         { return string(o) }
+
+// filterObjects is an Objects that records whether it was listed with a context.
+type filterObjects struct {
+	objects     []Object
+	err         error
+	withContext bool
+}
+
+func (f *filterObjects) ID() string { return "test" }
+
+func (f *filterObjects) List() ([]Object, error) { return f.objects, f.err }
+
+func (f *filterObjects) ListContext(ctx context.Context) ([]Object, error) {
+	f.withContext = true
+	return f.objects, f.err
+}
+
+func TestFilter(t *testing.T) {
+	partial := func(o Object) bool { return strings.HasSuffix(o.ID(), ".partial") }
+	for _, tc := range []struct {
+		id          string
+		objects     []Object
+		err         error
+		exclude     func(Object) bool
+		expectedIDs []string
+		expectedErr string
+	}{
+		{
+			id:          "Excludes matches",
+			objects:     []Object{filterObject("a.bz2"), filterObject("b.bz2.partial"), filterObject("c.bz2")},
+			exclude:     partial,
+			expectedIDs: []string{"a.bz2", "c.bz2"},
+		},
+		{
+			id:          "Nil exclude",
+			objects:     []Object{filterObject("a.bz2"), filterObject("b.bz2.partial")},
+			expectedIDs: []string{"a.bz2", "b.bz2.partial"},
+		},
+		{
+			id:          "Everything excluded",
+			objects:     []Object{filterObject("b.bz2.partial")},
+			exclude:     partial,
+			expectedIDs: []string{},
+		},
+		{
+			id:          "Error",
+			err:         errors.New("listing failed"),
+			exclude:     partial,
+			expectedErr: "listing failed",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		objects := &filterObjects{objects: tc.objects, err: tc.err}
+		f := Filter{Objects: objects, Exclude: tc.exclude}
+		if f.ID() != "test" {
+			t.Fatalf("Expected ID %q, got %q", "test", f.ID())
+		}
+		listed, err := f.List()
+		if tc.expectedErr != "" {
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Fatalf("Expected error %q, got %v", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Got unexpected error %q", err)
+		}
+		if !objects.withContext {
+			t.Fatalf("Expected ListContext to be used")
+		}
+		ids := []string{}
+		for _, o := range listed {
+			ids = append(ids, o.ID())
+		}
+		if !reflect.DeepEqual(tc.expectedIDs, ids) {
+			t.Fatalf("Expected %q, got %q", tc.expectedIDs, ids)
+		}
+	}
+}
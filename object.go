@@ -12,6 +12,7 @@ THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLI
 package agerotate
 
 import (
+	"context"
 	"time"
 )
 
@@ -33,6 +34,32 @@ type Objects interface {
 	List() ([]Object, error)
 }
 
+// ContextDeleter is optionally implemented by an Object whose deletion can be cancelled or bounded by a deadline.
+type ContextDeleter interface {
+	// DeleteContext attempts to remove the object, giving up if ctx is done.
+	DeleteContext(ctx context.Context) error
+}
+
+// ContextLister is optionally implemented by an Objects whose listing can be cancelled or bounded by a deadline.
+type ContextLister interface {
+	// ListContext retrieves all of the available Objects, giving up if ctx is done.
+	ListContext(ctx context.Context) ([]Object, error)
+}
+
+// Sizer is optionally implemented by an Object that knows how much space it occupies.
+type Sizer interface {
+	// Size returns the size of the object in bytes.
+	Size() int64
+}
+
+// SizeOf returns the size of o if it implements Sizer, otherwise zero.
+func SizeOf(o Object) int64 {
+	if sizer, ok := o.(Sizer); ok {
+		return sizer.Size()
+	}
+	return 0
+}
+
 // ObjectsByAge implements sort.Interface to sort Objects by Age, ascending.
 type ObjectsByAge struct {
 	O []Object
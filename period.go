@@ -0,0 +1,81 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package agerotate
+
+import (
+	"time"
+)
+
+// Period is a calendar unit used to group items by the local time they were created.
+type Period int
+
+const (
+	// NoPeriod means items aren't grouped by calendar.
+	NoPeriod Period = iota
+	// Day groups items by local calendar day.
+	Day
+	// Week groups items by ISO week, which starts on Monday.
+	Week
+	// Month groups items by calendar month.
+	Month
+	// Year groups items by calendar year.
+	Year
+)
+
+// String returns the name of the period.
+func (p Period) String() string {
+	switch p {
+	case Day:
+		return "day"
+	case Week:
+		return "week"
+	case Month:
+		return "month"
+	case Year:
+		return "year"
+	default:
+		return "none"
+	}
+}
+
+// Start returns the start of the period containing t, in t's location. For NoPeriod it returns t unchanged.
+func (p Period) Start(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch p {
+	case Day:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	case Week:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return t
+	}
+}
+
+// MaxLength returns the longest a period can be, ignoring daylight saving changes. For NoPeriod it returns zero.
+func (p Period) MaxLength() time.Duration {
+	switch p {
+	case Day:
+		return 24 * time.Hour
+	case Week:
+		return 7 * 24 * time.Hour
+	case Month:
+		return 31 * 24 * time.Hour
+	case Year:
+		return 366 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
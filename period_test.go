@@ -0,0 +1,102 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package agerotate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone database: %v", err)
+	}
+	for _, tc := range []struct {
+		id       string
+		period   Period
+		t        time.Time
+		expected time.Time
+	}{
+		{
+			id:       "Day",
+			period:   Day,
+			t:        time.Date(2026, time.October, 17, 23, 59, 0, 0, loc),
+			expected: time.Date(2026, time.October, 17, 0, 0, 0, 0, loc),
+		},
+		{
+			id:       "Day across DST change",
+			period:   Day,
+			t:        time.Date(2026, time.November, 1, 12, 0, 0, 0, loc),
+			expected: time.Date(2026, time.November, 1, 0, 0, 0, 0, loc),
+		},
+		{
+			id:       "Week from Saturday",
+			period:   Week,
+			t:        time.Date(2026, time.October, 17, 8, 0, 0, 0, loc),
+			expected: time.Date(2026, time.October, 12, 0, 0, 0, 0, loc),
+		},
+		{
+			id:       "Week from Sunday",
+			period:   Week,
+			t:        time.Date(2026, time.October, 18, 8, 0, 0, 0, loc),
+			expected: time.Date(2026, time.October, 12, 0, 0, 0, 0, loc),
+		},
+		{
+			id:       "Week from Monday",
+			period:   Week,
+			t:        time.Date(2026, time.October, 12, 0, 0, 0, 0, loc),
+			expected: time.Date(2026, time.October, 12, 0, 0, 0, 0, loc),
+		},
+		{
+			id:       "Week spanning a year",
+			period:   Week,
+			t:        time.Date(2027, time.January, 1, 8, 0, 0, 0, loc),
+			expected: time.Date(2026, time.December, 28, 0, 0, 0, 0, loc),
+		},
+		{
+			id:       "Month",
+			period:   Month,
+			t:        time.Date(2026, time.February, 28, 8, 0, 0, 0, loc),
+			expected: time.Date(2026, time.February, 1, 0, 0, 0, 0, loc),
+		},
+		{
+			id:       "Year",
+			period:   Year,
+			t:        time.Date(2026, time.December, 31, 23, 0, 0, 0, loc),
+			expected: time.Date(2026, time.January, 1, 0, 0, 0, 0, loc),
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		got := tc.period.Start(tc.t)
+		if !got.Equal(tc.expected) {
+			t.Fatalf("Expected %v, got %v", tc.expected, got)
+		}
+	}
+}
+
+func TestPeriodMaxLength(t *testing.T) {
+	for _, tc := range []struct {
+		period   Period
+		expected time.Duration
+	}{
+		{NoPeriod, 0},
+		{Day, 24 * time.Hour},
+		{Week, 168 * time.Hour},
+		{Month, 744 * time.Hour},
+		{Year, 8784 * time.Hour},
+	} {
+		t.Logf("Testing case %q", tc.period)
+		if got := tc.period.MaxLength(); got != tc.expected {
+			t.Fatalf("Expected %v, got %v", tc.expected, got)
+		}
+	}
+}
@@ -15,15 +15,32 @@ import (
 	"time"
 )
 
-// Range identifies a set of items for rotation. Age specifies the youngest items that belong to the set. Interval defines the minimum age gap between items to keep.
+// Range identifies a set of items for rotation. Age specifies the youngest items that belong to the set. Interval defines the minimum age gap between items to keep. If Aligned is set, time is instead divided into Interval-long slots aligned to a fixed anchor and the oldest item in each slot is kept, so the items kept don't depend on when rotation runs. If Period is set, Interval is ignored and the youngest item in each calendar period is kept instead. If Selector is set, it chooses the items to keep instead of any of these rules. If Count is non-zero the Range instead holds the Count youngest items regardless of their age, all of which are kept, and Age and Interval are ignored. Count ranges claim their items before any Age ranges.
 type Range struct {
 	Age      time.Duration
 	Interval time.Duration
+	Aligned  bool
+	Period   Period
+	Count    int
+	Selector Selector
 }
 
-// String profiles a human-readable string for a range.
+// String profiles a human-readable string for a range. Durations are written by FormatDuration.
 func (r Range) String() string {
-	return fmt.Sprintf("For files younger than %s, keep one every %s", r.Age, r.Interval)
+	if r.Count > 0 {
+		return fmt.Sprintf("Keep the %d youngest files", r.Count)
+	}
+	// Selector comes first because it takes precedence over Period and Aligned when planning.
+	if r.Selector != nil {
+		return fmt.Sprintf("For files younger than %s, keep one every %s using %s", FormatDuration(r.Age), FormatDuration(r.Interval), r.Selector)
+	}
+	if r.Period != NoPeriod {
+		return fmt.Sprintf("For files younger than %s, keep the last one each %s", FormatDuration(r.Age), r.Period)
+	}
+	if r.Aligned && r.Interval > 0 {
+		return fmt.Sprintf("For files younger than %s, keep one per aligned %s slot", FormatDuration(r.Age), FormatDuration(r.Interval))
+	}
+	return fmt.Sprintf("For files younger than %s, keep one every %s", FormatDuration(r.Age), FormatDuration(r.Interval))
 }
 
 // ByAge implements sort.Interface to sort Range objects by Age, ascending.
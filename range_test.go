@@ -16,33 +16,92 @@ import (
 	"time"
 )
 
+// testSelector is a Selector that keeps everything, for checking how ranges using a Selector are described.
+type testSelector struct{}
+
+func (testSelector) String() string { return "test" }
+
+func (testSelector) Select(r Range, objects []Object, t Timeline) []Selection {
+	selections := make([]Selection, len(objects))
+	for i := range selections {
+		selections[i] = Selection{Keep: true, Reason: "test"}
+	}
+	return selections
+}
+
 func TestRangeString(t *testing.T) {
 	for _, tc := range []struct {
 		id            string
 		age, interval time.Duration
+		aligned       bool
+		period        Period
+		selector      Selector
 		expected      string
 	}{
 		{
 			id:       "3 days @ 12 hours",
 			age:      3 * 24 * time.Hour,
 			interval: 12 * time.Hour,
-			expected: "For files younger than 72h0m0s, keep one every 12h0m0s",
+			expected: "For files younger than 3d, keep one every 12h",
 		},
 		{
 			id:       "12 hours @ 3 hours",
 			age:      12 * time.Hour,
 			interval: 3 * time.Hour,
-			expected: "For files younger than 12h0m0s, keep one every 3h0m0s",
+			expected: "For files younger than 12h, keep one every 3h",
+		},
+		{
+			id:       "Aligned 12 hours @ 3 hours",
+			age:      12 * time.Hour,
+			interval: 3 * time.Hour,
+			aligned:  true,
+			expected: "For files younger than 12h, keep one per aligned 3h slot",
+		},
+		{
+			id:       "Aligned keep all",
+			age:      12 * time.Hour,
+			aligned:  true,
+			expected: "For files younger than 12h, keep one every 0s",
+		},
+		{
+			id:       "Period",
+			age:      30 * 24 * time.Hour,
+			period:   Day,
+			expected: "For files younger than 1mo, keep the last one each day",
+		},
+		{
+			id:       "Selector overrides period",
+			age:      30 * 24 * time.Hour,
+			interval: 6 * time.Hour,
+			period:   Day,
+			selector: testSelector{},
+			expected: "For files younger than 1mo, keep one every 6h using test",
+		},
+		{
+			id:       "Selector overrides alignment",
+			age:      12 * time.Hour,
+			interval: 3 * time.Hour,
+			aligned:  true,
+			selector: testSelector{},
+			expected: "For files younger than 12h, keep one every 3h using test",
 		},
 	} {
 		t.Logf("Testing case %q", tc.id)
-		r := Range{tc.age, tc.interval}
+		r := Range{Age: tc.age, Interval: tc.interval, Aligned: tc.aligned, Period: tc.period, Selector: tc.selector}
 		if r.String() != tc.expected {
 			t.Fatalf("Got %q, expected %q", r, tc.expected)
 		}
 	}
 }
 
+func TestCountRangeString(t *testing.T) {
+	expected := "Keep the 10 youngest files"
+	r := Range{Age: time.Hour, Count: 10}
+	if r.String() != expected {
+		t.Fatalf("Got %q, expected %q", r, expected)
+	}
+}
+
 func TestByAgeSort(t *testing.T) {
 	for _, tc := range []struct {
 		id       string
@@ -0,0 +1,66 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package agerotate
+
+import (
+	"time"
+)
+
+// Selector chooses which of the objects within a Range to keep.
+type Selector interface {
+	// Select decides whether to keep each of objects, which all belong to r and are sorted by Age, youngest first. It returns one Selection per object in the same order.
+	Select(r Range, objects []Object, t Timeline) []Selection
+	// String returns a short name for the selector.
+	String() string
+}
+
+// Selection is a Selector's decision about one object.
+type Selection struct {
+	// Keep is true if the object should be retained.
+	Keep bool
+	// Reason is a human-readable explanation of the decision.
+	Reason string
+	// Neighbor is the kept object the decision was made against, such as the last kept object an Interval is measured from or the object kept instead for the same slot. It's nil if the decision didn't depend on another object.
+	Neighbor Object
+}
+
+// Timeline relates object ages to wall-clock time for selectors that work with calendar periods or slots.
+type Timeline struct {
+	// Now is the moment ages are measured from.
+	Now time.Time
+	// Location is the time zone calendar periods are computed in. Nil means time.Local.
+	Location *time.Location
+	// Anchor is the time slots are aligned to.
+	Anchor time.Time
+}
+
+// At returns the local time at which an object of the given age was created.
+func (t Timeline) At(age time.Duration) time.Time {
+	return t.Now.Add(-age).In(t.location())
+}
+
+// SlotStart returns the start of the slot containing the creation time of an object of the given age. Slots are interval long and aligned to Anchor. The interval must be positive.
+func (t Timeline) SlotStart(age, interval time.Duration) time.Time {
+	offset := t.At(age).Sub(t.Anchor)
+	n := offset / interval
+	if offset%interval < 0 {
+		n--
+	}
+	return t.Anchor.Add(n * interval).In(t.location())
+}
+
+// location returns Location, or time.Local if it's nil.
+func (t Timeline) location() *time.Location {
+	if t.Location == nil {
+		return time.Local
+	}
+	return t.Location
+}
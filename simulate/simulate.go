@@ -0,0 +1,257 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+// simulate runs a rotation schedule against synthetic objects to show how many objects each range holds over time and where gaps appear, without touching real data.
+package simulate
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+	"github.com/AgentZombie/agerotate/bucket"
+)
+
+// Config describes a simulation: the schedule being tested, how often objects are created, and how long to run for.
+type Config struct {
+	// Ranges are the sorted ranges under test.
+	Ranges []agerotate.Range
+	// Cleaner holds the settings used for each rotation. Its Now is set by the simulation.
+	Cleaner bucket.Cleaner
+	// Start is when the first object is created.
+	Start time.Time
+	// Span is how long the simulation covers.
+	Span time.Duration
+	// Every is the time between object creations.
+	Every time.Duration
+	// Jitter moves each creation time by a random amount up to Jitter earlier or later.
+	Jitter time.Duration
+	// Step is the time between rotations. Zero means Every.
+	Step time.Duration
+	// Size is the size of each object in bytes, for schedules using MaxBytes.
+	Size int64
+	// Seed seeds the random jitter so runs can be repeated.
+	Seed int64
+}
+
+// Report summarizes a simulation.
+type Report struct {
+	// Runs is the number of rotations performed.
+	Runs int
+	// SteadyRuns is the number of rotations made once the simulation had run longer than the oldest range, so that every range could be full.
+	SteadyRuns int
+	// Created is the number of objects created.
+	Created int
+	// Deleted is the number of objects deleted.
+	Deleted int
+	// Blocked is the number of rotations where a MaxDelete or MaxDeletePercent limit stopped every deletion.
+	Blocked int
+	// Ranges has an entry for each range, in order, followed by one for objects older than every range.
+	Ranges []RangeReport
+	// Survivors are the creation times of the objects left at the end, youngest first.
+	Survivors []time.Time
+	// Gaps are the stretches between adjacent survivors longer than twice the spacing their range aims for.
+	Gaps []Gap
+}
+
+// RangeReport counts the objects a range held.
+type RangeReport struct {
+	// Range is the range being counted. It's nil for objects older than every range.
+	Range *agerotate.Range
+	// Min and Max are the fewest and most objects the range held after any steady-state rotation.
+	Min, Max int
+	// Final is the number of objects the range held after the last rotation.
+	Final int
+	// MaxGap is the longest time between adjacent survivors in the range at the end.
+	MaxGap time.Duration
+}
+
+// Gap is a stretch of time with no surviving objects.
+type Gap struct {
+	// Range is the range of the older survivor bounding the gap.
+	Range *agerotate.Range
+	// Younger and Older are the ages, at the end of the simulation, of the survivors on each side of the gap. A gap between the oldest survivor and the end of the oldest range has that range's Age as Older.
+	Younger, Older time.Duration
+}
+
+// Run performs the simulation. Objects are created every Every from Start with random Jitter, and every Step a plan is made with the Cleaner as of that moment and applied to the in-memory objects.
+func Run(c Config) (Report, error) {
+	if c.Every <= 0 {
+		return Report{}, fmt.Errorf("Creation interval must be positive, got %v", c.Every)
+	}
+	if c.Span <= 0 {
+		return Report{}, fmt.Errorf("Span must be positive, got %v", c.Span)
+	}
+	if c.Step < 0 {
+		return Report{}, fmt.Errorf("Step must not be negative, got %v", c.Step)
+	}
+	step := c.Step
+	if step == 0 {
+		step = c.Every
+	}
+	oldest := time.Duration(0)
+	for _, r := range c.Ranges {
+		if r.Age > oldest {
+			oldest = r.Age
+		}
+	}
+
+	report := Report{Ranges: make([]RangeReport, len(c.Ranges)+1)}
+	for i := range c.Ranges {
+		report.Ranges[i].Range = &c.Ranges[i]
+	}
+	rng := rand.New(rand.NewSource(c.Seed))
+	store := &Store{}
+	end := c.Start.Add(c.Span)
+	next := 0
+	var held bucket.Decisions
+	for now := c.Start.Add(step); !now.After(end); now = now.Add(step) {
+		for ; !c.Start.Add(time.Duration(next) * c.Every).After(now); next++ {
+			created := c.Start.Add(time.Duration(next) * c.Every)
+			if c.Jitter > 0 {
+				created = created.Add(time.Duration(rng.Int63n(int64(2*c.Jitter+1))) - c.Jitter)
+			}
+			store.Add(created, c.Size)
+			report.Created++
+		}
+
+		store.Now = now
+		cleaner := c.Cleaner
+		cleaner.Now = now
+		cleaner.ContinueOnError = false
+		cleaner.Workers = 0
+		decisions, err := cleaner.Plan(c.Ranges, store)
+		if err != nil {
+			return report, err
+		}
+		result, err := cleaner.Apply(decisions)
+		if _, ok := err.(*bucket.LimitError); ok {
+			report.Blocked++
+			held = decisions
+		} else if err != nil {
+			return report, err
+		} else {
+			held = kept(decisions)
+		}
+		report.Runs++
+		report.Deleted += result.Deleted
+
+		if now.Sub(c.Start) < oldest {
+			continue
+		}
+		counts := report.count(held)
+		for i := range report.Ranges {
+			rr := &report.Ranges[i]
+			if report.SteadyRuns == 0 || counts[i] < rr.Min {
+				rr.Min = counts[i]
+			}
+			if counts[i] > rr.Max {
+				rr.Max = counts[i]
+			}
+		}
+		report.SteadyRuns++
+	}
+
+	counts := report.count(held)
+	for i := range report.Ranges {
+		report.Ranges[i].Final = counts[i]
+	}
+	report.findGaps(c, store, held, oldest)
+	return report, nil
+}
+
+// kept returns the decisions that keep their objects.
+func kept(decisions bucket.Decisions) bucket.Decisions {
+	k := bucket.Decisions{}
+	for _, d := range decisions {
+		if d.Keep {
+			k = append(k, d)
+		}
+	}
+	return k
+}
+
+// count returns the number of held objects in each entry in report.Ranges.
+func (report Report) count(held bucket.Decisions) []int {
+	counts := make([]int, len(report.Ranges))
+	for _, d := range held {
+		counts[report.rangeIndex(d.Range)]++
+	}
+	return counts
+}
+
+// findGaps records the survivors at the end of the simulation, the longest gap in each range, and every gap longer than twice the spacing of its range. If the simulation ran longer than the oldest range, the time between the oldest survivor and the end of that range is checked too.
+func (report *Report) findGaps(c Config, store *Store, held bucket.Decisions, oldest time.Duration) {
+	survivors := append(bucket.Decisions{}, held...)
+	sort.SliceStable(survivors, func(i, j int) bool {
+		return survivors[i].Object.Age() < survivors[j].Object.Age()
+	})
+	report.Survivors = make([]time.Time, len(survivors))
+	report.Gaps = []Gap{}
+	for i, d := range survivors {
+		report.Survivors[i] = store.Now.Add(-d.Object.Age())
+		if i == 0 {
+			continue
+		}
+		younger, older := survivors[i-1].Object.Age(), d.Object.Age()
+		index := report.rangeIndex(d.Range)
+		rr := &report.Ranges[index]
+		if gap := older - younger; gap > rr.MaxGap && report.rangeIndex(survivors[i-1].Range) == index {
+			rr.MaxGap = gap
+		}
+		if older-younger > 2*spacing(d.Range, c.Every) {
+			report.Gaps = append(report.Gaps, Gap{Range: rr.Range, Younger: younger, Older: older})
+		}
+	}
+
+	if oldest == 0 || store.Now.Sub(c.Start) < oldest {
+		return
+	}
+	index := 0
+	for i := range c.Ranges {
+		if c.Ranges[i].Age == oldest {
+			index = i
+		}
+	}
+	younger := time.Duration(0)
+	if len(survivors) > 0 {
+		younger = survivors[len(survivors)-1].Object.Age()
+	}
+	if oldest-younger > 2*spacing(&c.Ranges[index], c.Every) {
+		report.Gaps = append(report.Gaps, Gap{Range: report.Ranges[index].Range, Younger: younger, Older: oldest})
+	}
+}
+
+// rangeIndex returns the index in report.Ranges of the range a decision points to. Decisions for objects older than every range have a nil Range, which matches the last entry.
+func (report Report) rangeIndex(r *agerotate.Range) int {
+	for i := range report.Ranges {
+		if report.Ranges[i].Range == r {
+			return i
+		}
+	}
+	return len(report.Ranges) - 1
+}
+
+// spacing returns the time a range aims to leave between the objects it keeps.
+func spacing(r *agerotate.Range, every time.Duration) time.Duration {
+	if r == nil {
+		return every
+	}
+	s := r.Interval
+	if r.Period != agerotate.NoPeriod {
+		s = r.Period.MaxLength()
+	}
+	if s < every {
+		return every
+	}
+	return s
+}
@@ -0,0 +1,168 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package simulate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+	"github.com/AgentZombie/agerotate/bucket"
+)
+
+func TestRun(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		id                 string
+		config             Config
+		expectedErr        string
+		expectedRuns       int
+		expectedSteadyRuns int
+		expectedCreated    int
+		expectedDeleted    int
+		expectedBlocked    int
+		expectedFinal      []int
+		expectedGaps       []Gap
+	}{
+		{
+			id: "Hourly, youngest",
+			config: Config{
+				Ranges: []agerotate.Range{{Age: 24 * time.Hour}, {Age: 72 * time.Hour, Interval: 6 * time.Hour}},
+				Start:  start,
+				Span:   240 * time.Hour,
+				Every:  time.Hour,
+			},
+			expectedRuns:       240,
+			expectedSteadyRuns: 169,
+			expectedCreated:    241,
+			expectedDeleted:    216,
+			expectedFinal:      []int{24, 1, 0},
+			expectedGaps:       []Gap{{Younger: 24 * time.Hour, Older: 72 * time.Hour}},
+		},
+		{
+			id: "Hourly, aligned",
+			config: Config{
+				Ranges: []agerotate.Range{{Age: 24 * time.Hour}, {Age: 72 * time.Hour, Interval: 6 * time.Hour, Aligned: true}},
+				Start:  start,
+				Span:   240 * time.Hour,
+				Every:  time.Hour,
+			},
+			expectedRuns:       240,
+			expectedSteadyRuns: 169,
+			expectedCreated:    241,
+			expectedDeleted:    209,
+			expectedFinal:      []int{24, 8, 0},
+			expectedGaps:       []Gap{},
+		},
+		{
+			id: "Blocked by MaxDelete",
+			config: Config{
+				Ranges:  []agerotate.Range{{Age: 24 * time.Hour}},
+				Cleaner: bucket.Cleaner{MaxDelete: 1},
+				Start:   start,
+				Span:    96 * time.Hour,
+				Every:   time.Hour,
+				Step:    48 * time.Hour,
+			},
+			expectedRuns:       2,
+			expectedSteadyRuns: 2,
+			expectedCreated:    97,
+			expectedBlocked:    2,
+			expectedFinal:      []int{24, 73},
+			expectedGaps:       []Gap{},
+		},
+		{
+			id:          "No cadence",
+			config:      Config{Span: time.Hour},
+			expectedErr: "Creation interval must be positive, got 0s",
+		},
+		{
+			id:          "No span",
+			config:      Config{Every: time.Hour},
+			expectedErr: "Span must be positive, got 0s",
+		},
+	} {
+		t.Logf("Testing case %q", tc.id)
+		report, err := Run(tc.config)
+		if tc.expectedErr != "" {
+			if err == nil {
+				t.Fatalf("Expected err %q, got nil", tc.expectedErr)
+			}
+			if err.Error() != tc.expectedErr {
+				t.Fatalf("Expected error %q, got %q", tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected err: %q", err)
+		}
+		if report.Runs != tc.expectedRuns || report.SteadyRuns != tc.expectedSteadyRuns || report.Created != tc.expectedCreated || report.Deleted != tc.expectedDeleted || report.Blocked != tc.expectedBlocked {
+			t.Fatalf("Expected %d runs, %d steady, %d created, %d deleted, %d blocked, got %+v", tc.expectedRuns, tc.expectedSteadyRuns, tc.expectedCreated, tc.expectedDeleted, tc.expectedBlocked, report)
+		}
+		if len(report.Ranges) != len(tc.expectedFinal) {
+			t.Fatalf("Expected %d ranges, got %d", len(tc.expectedFinal), len(report.Ranges))
+		}
+		for i, rr := range report.Ranges {
+			if rr.Final != tc.expectedFinal[i] {
+				t.Fatalf("Expected range %d to hold %d objects, got %+v", i, tc.expectedFinal[i], rr)
+			}
+			if rr.Min > rr.Final || rr.Max < rr.Final {
+				t.Fatalf("Expected range %d min and max to bracket its final count, got %+v", i, rr)
+			}
+		}
+		if len(report.Survivors) != report.Created-report.Deleted {
+			t.Fatalf("Expected %d survivors, got %d", report.Created-report.Deleted, len(report.Survivors))
+		}
+		if len(report.Gaps) != len(tc.expectedGaps) {
+			t.Fatalf("Expected gaps %+v, got %+v", tc.expectedGaps, report.Gaps)
+		}
+		for i := range tc.expectedGaps {
+			if report.Gaps[i].Younger != tc.expectedGaps[i].Younger || report.Gaps[i].Older != tc.expectedGaps[i].Older {
+				t.Fatalf("Expected gaps %+v, got %+v", tc.expectedGaps, report.Gaps)
+			}
+		}
+	}
+}
+
+func TestRunJitter(t *testing.T) {
+	config := Config{
+		Ranges: []agerotate.Range{{Age: 24 * time.Hour, Interval: 4 * time.Hour}},
+		Start:  time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Span:   72 * time.Hour,
+		Every:  time.Hour,
+		Jitter: 20 * time.Minute,
+		Seed:   42,
+	}
+	first, err := Run(config)
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	second, err := Run(config)
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	if len(first.Survivors) != len(second.Survivors) {
+		t.Fatalf("Expected the same seed to give the same survivors, got %v and %v", first.Survivors, second.Survivors)
+	}
+	jittered := false
+	for i := range first.Survivors {
+		if !first.Survivors[i].Equal(second.Survivors[i]) {
+			t.Fatalf("Expected the same seed to give the same survivors, got %v and %v", first.Survivors, second.Survivors)
+		}
+		if first.Survivors[i].Minute() != 0 {
+			jittered = true
+		}
+	}
+	if !jittered {
+		t.Fatalf("Expected jittered creation times, got %v", first.Survivors)
+	}
+}
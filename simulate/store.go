@@ -0,0 +1,89 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package simulate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AgentZombie/agerotate"
+)
+
+// Object is an in-memory object created at a fixed time. Its age is measured from the Now of the Store holding it.
+type Object struct {
+	store   *Store
+	created time.Time
+	size    int64
+}
+
+// Created returns the time the object was created.
+func (o *Object) Created() time.Time {
+	return o.created
+}
+
+// ID returns the creation time of the object in RFC 3339 format.
+func (o *Object) ID() string {
+	return o.created.Format(time.RFC3339)
+}
+
+// Age returns the time between the object's creation and the store's Now.
+func (o *Object) Age() time.Duration {
+	return o.store.Now.Sub(o.created)
+}
+
+// Size returns the size given when the object was added.
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// Delete removes the object from its store. It's an error to delete an object twice.
+func (o *Object) Delete() error {
+	for i, stored := range o.store.objects {
+		if stored == o {
+			o.store.objects = append(o.store.objects[:i], o.store.objects[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("Object %s already deleted", o.ID())
+}
+
+// Store is an in-memory implementation of agerotate.Objects. Objects are listed in the order they were added.
+type Store struct {
+	// Now is the moment object ages are measured from.
+	Now     time.Time
+	objects []*Object
+}
+
+// Add creates an object with the given creation time and size.
+func (s *Store) Add(created time.Time, size int64) *Object {
+	o := &Object{store: s, created: created, size: size}
+	s.objects = append(s.objects, o)
+	return o
+}
+
+// ID returns a fixed name for the store.
+func (s *Store) ID() string {
+	return "simulation"
+}
+
+// List returns every object that hasn't been deleted.
+func (s *Store) List() ([]agerotate.Object, error) {
+	objects := make([]agerotate.Object, len(s.objects))
+	for i := range s.objects {
+		objects[i] = s.objects[i]
+	}
+	return objects, nil
+}
+
+// Len returns the number of objects that haven't been deleted.
+func (s *Store) Len() int {
+	return len(s.objects)
+}
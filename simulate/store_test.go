@@ -0,0 +1,49 @@
+/* Copyright (c) 2016 Jason Mansfield
+
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package simulate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store := &Store{Now: start.Add(3 * time.Hour)}
+	first := store.Add(start, 10)
+	second := store.Add(start.Add(time.Hour), 20)
+
+	if first.Age() != 3*time.Hour || second.Age() != 2*time.Hour {
+		t.Fatalf("Expected ages 3h and 2h, got %v and %v", first.Age(), second.Age())
+	}
+	store.Now = store.Now.Add(time.Hour)
+	if first.Age() != 4*time.Hour {
+		t.Fatalf("Expected age to follow Now, got %v", first.Age())
+	}
+	if first.ID() != "2026-01-01T00:00:00Z" || second.Size() != 20 {
+		t.Fatalf("Unexpected ID %q or size %d", first.ID(), second.Size())
+	}
+
+	if err := first.Delete(); err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	objects, err := store.List()
+	if err != nil {
+		t.Fatalf("Unexpected err: %q", err)
+	}
+	if len(objects) != 1 || objects[0] != second || store.Len() != 1 {
+		t.Fatalf("Expected only the second object to remain, got %v", objects)
+	}
+	expected := "Object 2026-01-01T00:00:00Z already deleted"
+	if err := first.Delete(); err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %q, got %v", expected, err)
+	}
+}